@@ -0,0 +1,38 @@
+package main
+
+import (
+	"log"
+	"os"
+
+	"github.com/jingqiuELE/advent_code_2019/intcode"
+	flag "github.com/spf13/pflag"
+)
+
+func main() {
+	var dataFile string
+	var scriptFile string
+
+	flag.StringVarP(&dataFile, "data file name", "f", "", "")
+	flag.StringVarP(&scriptFile, "script", "s", "", "answer the prompts with the lines of this file instead of the terminal")
+	flag.Parse()
+
+	program, err := intcode.BuildList(dataFile)
+	if err != nil {
+		log.Fatal("Failed to get program from input file!", err)
+	}
+
+	session := intcode.NewASCII(intcode.NewMachine(program))
+	answers := os.Stdin
+	if scriptFile != "" {
+		answers, err = os.Open(scriptFile)
+		if err != nil {
+			log.Fatal("Failed to open script file!", err)
+		}
+		defer answers.Close()
+		session.Echo = true
+	}
+
+	if err := session.Run(answers, os.Stdout); err != nil {
+		log.Fatal("Failed to run the program!", err)
+	}
+}
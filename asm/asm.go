@@ -0,0 +1,44 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"log"
+	"strings"
+
+	"github.com/jingqiuELE/advent_code_2019/intcode"
+	flag "github.com/spf13/pflag"
+)
+
+func main() {
+	var sourceFile string
+	var outputFile string
+
+	flag.StringVarP(&sourceFile, "source file name", "f", "", "")
+	flag.StringVarP(&outputFile, "output file name", "o", "", "defaults to stdout")
+	flag.Parse()
+
+	src, err := ioutil.ReadFile(sourceFile)
+	if err != nil {
+		log.Fatal("Failed to read source file!", err)
+	}
+
+	program, err := intcode.Assemble(string(src))
+	if err != nil {
+		log.Fatal("Failed to assemble! ", err)
+	}
+
+	words := make([]string, len(program))
+	for i, v := range program {
+		words[i] = fmt.Sprint(v)
+	}
+	text := strings.Join(words, ",") + "\n"
+
+	if outputFile == "" {
+		fmt.Print(text)
+		return
+	}
+	if err := ioutil.WriteFile(outputFile, []byte(text), 0644); err != nil {
+		log.Fatal("Failed to write output file!", err)
+	}
+}
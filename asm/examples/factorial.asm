@@ -0,0 +1,27 @@
+; Reads n and prints n!, computed by a recursive subroutine.
+
+        ARB  #stack
+        IN   [n]
+        push [n]
+        call fact
+        pop  [result]
+        OUT  [result]
+        HLT
+
+; fact replaces its argument, stored just below the return address, with
+; the argument's factorial.
+fact:   JT   rb-2, #recurse
+        ADD  #1, #0, rb-2
+        ret
+recurse:
+        ADD  rb-2, #-1, rb+0
+        ARB  #1
+        call fact
+        pop  [tmp]
+        MUL  rb-2, [tmp], rb-2
+        ret
+
+n:      db 0
+result: db 0
+tmp:    db 0
+stack:
@@ -0,0 +1,17 @@
+; Prints a string with a user macro and a loop over its characters.
+
+macro print_at ptr
+        ADD  ptr, #0, [load+1]
+load:   OUT  [0]
+endm
+
+loop:   print_at [ptr]
+        ADD  [ptr], #1, [ptr]
+        EQ   [ptr], #end, [done]
+        JF   [done], #loop
+        HLT
+
+ptr:    db text
+done:   db 0
+text:   db "Hello, Intcode!", 10
+end:
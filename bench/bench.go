@@ -0,0 +1,42 @@
+package main
+
+//go:generate go run ../compile -f fib.asm -o fib_compiled.go -n fib
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/jingqiuELE/advent_code_2019/intcode"
+	flag "github.com/spf13/pflag"
+)
+
+func main() {
+	var n int
+
+	flag.IntVarP(&n, "fib", "n", 20, "argument of the fib workload")
+	flag.Parse()
+
+	interpreted := func() *intcode.Machine {
+		m := intcode.NewMachine(fibProgram)
+		m.Feed(n)
+		if err := m.Run(nil, nil); err != nil {
+			log.Fatal("Failed to run fib!", err)
+		}
+		return m
+	}
+	compiled := func() *intcode.Machine {
+		m := intcode.NewMachine(fibProgram)
+		m.Feed(n)
+		if err := m.RunCompiled(fib, nil, nil); err != nil {
+			log.Fatal("Failed to run compiled fib!", err)
+		}
+		return m
+	}
+
+	want, got := interpreted(), compiled()
+	result := want.TakeOutput()
+	if out := got.TakeOutput(); fmt.Sprint(out) != fmt.Sprint(result) || got.Steps != want.Steps {
+		log.Fatal("Compiled fib disagrees!", out, got.Steps)
+	}
+	fmt.Printf("fib(%d) = %v in %d steps\n", n, result, want.Steps)
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/jingqiuELE/advent_code_2019/intcode"
+)
+
+func runFib(t testing.TB, n int, compiled bool) *intcode.Machine {
+	m := intcode.NewMachine(fibProgram)
+	m.Feed(n)
+	var err error
+	if compiled {
+		err = m.RunCompiled(fib, nil, nil)
+	} else {
+		err = m.Run(nil, nil)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestCompiledFib(t *testing.T) {
+	for n := 0; n <= 15; n++ {
+		want, got := runFib(t, n, false), runFib(t, n, true)
+		if w, g := fmt.Sprint(want.TakeOutput()), fmt.Sprint(got.TakeOutput()); g != w || got.Steps != want.Steps {
+			t.Errorf("fib(%d): compiled gives %v in %d steps, interpreter %v in %d", n, g, got.Steps, w, want.Steps)
+		}
+	}
+}
+
+func BenchmarkInterpreted(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		runFib(b, 20, false)
+	}
+}
+
+func BenchmarkCompiled(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		runFib(b, 20, true)
+	}
+}
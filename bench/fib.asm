@@ -0,0 +1,34 @@
+; Recursive Fibonacci on the relative base stack: a call-heavy workload in
+; the style of BOOST, reading n and writing fib(n).
+
+start:  ARB  #stack
+        IN   [n]
+        push [n]
+        call fib
+        pop
+        OUT  [res]
+        HLT
+
+; fib(n) leaves its result in [res]; n is the argument below the return
+; address.
+fib:    LT   rb-2, #2, [t]
+        JF   [t], #rec
+        ADD  rb-2, #0, [res]
+        ret
+rec:    ADD  rb-2, #-1, rb+0
+        ARB  #1
+        call fib
+        pop
+        push [res]
+        ADD  rb-3, #-2, rb+0
+        ARB  #1
+        call fib
+        pop
+        pop  [t]
+        ADD  [t], [res], [res]
+        ret
+
+n:      db 0
+res:    db 0
+t:      db 0
+stack:
@@ -0,0 +1,381 @@
+// Code generated by intcode.Compile. DO NOT EDIT.
+
+package main
+
+import "github.com/jingqiuELE/advent_code_2019/intcode"
+
+var fibProgram = []int{
+	109, 98, 3, 95, 21001, 95, 0, 0, 109, 1, 21101, 19, 0, 0, 109, 1,
+	1105, 1, 24, 109, -1, 4, 96, 99, 1207, -2, 2, 97, 1006, 97, 40, 1201,
+	-2, 0, 96, 109, -1, 2105, 1, 0, 21201, -2, -1, 0, 109, 1, 21101, 55,
+	0, 0, 109, 1, 1105, 1, 24, 109, -1, 21001, 96, 0, 0, 109, 1, 21201,
+	-3, -2, 0, 109, 1, 21101, 78, 0, 0, 109, 1, 1105, 1, 24, 109, -1,
+	109, -1, 1201, 0, 0, 97, 1, 97, 96, 96, 109, -1, 2105, 1, 0, 0,
+	0, 0,
+}
+
+// fibCode marks the cells holding compiled instructions.
+var fibCode = [...]bool{
+	0: true, 1: true, 2: true, 3: true, 4: true, 5: true, 6: true, 7: true, 8: true, 9: true, 10: true, 11: true,
+	12: true, 13: true, 14: true, 15: true, 16: true, 17: true, 18: true, 19: true, 20: true, 21: true, 22: true, 23: true,
+	24: true, 25: true, 26: true, 27: true, 28: true, 29: true, 30: true, 31: true, 32: true, 33: true, 34: true, 35: true,
+	36: true, 37: true, 38: true, 39: true, 40: true, 41: true, 42: true, 43: true, 44: true, 45: true, 46: true, 47: true,
+	48: true, 49: true, 50: true, 51: true, 52: true, 53: true, 54: true, 55: true, 56: true, 57: true, 58: true, 59: true,
+	60: true, 61: true, 62: true, 63: true, 64: true, 65: true, 66: true, 67: true, 68: true, 69: true, 70: true, 71: true,
+	72: true, 73: true, 74: true, 75: true, 76: true, 77: true, 78: true, 79: true, 80: true, 81: true, 82: true, 83: true,
+	84: true, 85: true, 86: true, 87: true, 88: true, 89: true, 90: true, 91: true, 92: true, 93: true, 94: true,
+}
+
+func fib(m *intcode.Machine) bool {
+	mem := m.Memory
+	ip, rb := m.IP, m.RelativeBase
+	var steps int64
+	defer func() {
+		m.IP, m.RelativeBase = ip, rb
+		m.Steps += steps
+	}()
+
+	for {
+		switch ip {
+		case 0: // ARB #98
+			a := 98
+			rb += a
+			ip = 2
+			steps++
+			fallthrough
+		case 2: // IN  [95]
+			v, err := m.Input()
+			if err != nil {
+				return false
+			}
+			if err := mem.Store(95, v); err != nil {
+				return false
+			}
+			ip = 4
+			steps++
+			fallthrough
+		case 4: // ADD [95], #0, rb+0
+			a, err := mem.Load(95)
+			if err != nil {
+				return false
+			}
+			b := 0
+			v := a + b
+			t := rb + 0
+			if err := mem.Store(t, v); err != nil {
+				return false
+			}
+			if t >= 0 && t < len(fibCode) && fibCode[t] {
+				ip = 8
+				steps++
+				return true
+			}
+			ip = 8
+			steps++
+			fallthrough
+		case 8: // ARB #1
+			a := 1
+			rb += a
+			ip = 10
+			steps++
+			fallthrough
+		case 10: // ADD #19, #0, rb+0
+			a := 19
+			b := 0
+			v := a + b
+			t := rb + 0
+			if err := mem.Store(t, v); err != nil {
+				return false
+			}
+			if t >= 0 && t < len(fibCode) && fibCode[t] {
+				ip = 14
+				steps++
+				return true
+			}
+			ip = 14
+			steps++
+			fallthrough
+		case 14: // ARB #1
+			a := 1
+			rb += a
+			ip = 16
+			steps++
+			fallthrough
+		case 16: // JT  #1, #24
+			b := 24
+			ip = b
+			steps++
+			continue
+		case 19: // ARB #-1
+			a := -1
+			rb += a
+			ip = 21
+			steps++
+			fallthrough
+		case 21: // OUT [96]
+			a, err := mem.Load(96)
+			if err != nil {
+				return false
+			}
+			if err := m.Output(a); err != nil {
+				return false
+			}
+			ip = 23
+			steps++
+			fallthrough
+		case 23: // HLT
+			m.Halted = true
+			steps++
+			return false
+		case 24: // LT  rb-2, #2, [97]
+			a, err := mem.Load(rb + -2)
+			if err != nil {
+				return false
+			}
+			b := 2
+			v := 0
+			if a < b {
+				v = 1
+			}
+			if err := mem.Store(97, v); err != nil {
+				return false
+			}
+			ip = 28
+			steps++
+			fallthrough
+		case 28: // JF  [97], #40
+			a, err := mem.Load(97)
+			if err != nil {
+				return false
+			}
+			b := 40
+			if a == 0 {
+				ip = b
+				steps++
+				continue
+			}
+			ip = 31
+			steps++
+			fallthrough
+		case 31: // ADD rb-2, #0, [96]
+			a, err := mem.Load(rb + -2)
+			if err != nil {
+				return false
+			}
+			b := 0
+			v := a + b
+			if err := mem.Store(96, v); err != nil {
+				return false
+			}
+			ip = 35
+			steps++
+			fallthrough
+		case 35: // ARB #-1
+			a := -1
+			rb += a
+			ip = 37
+			steps++
+			fallthrough
+		case 37: // JT  #1, rb+0
+			b, err := mem.Load(rb + 0)
+			if err != nil {
+				return false
+			}
+			ip = b
+			steps++
+			continue
+		case 40: // ADD rb-2, #-1, rb+0
+			a, err := mem.Load(rb + -2)
+			if err != nil {
+				return false
+			}
+			b := -1
+			v := a + b
+			t := rb + 0
+			if err := mem.Store(t, v); err != nil {
+				return false
+			}
+			if t >= 0 && t < len(fibCode) && fibCode[t] {
+				ip = 44
+				steps++
+				return true
+			}
+			ip = 44
+			steps++
+			fallthrough
+		case 44: // ARB #1
+			a := 1
+			rb += a
+			ip = 46
+			steps++
+			fallthrough
+		case 46: // ADD #55, #0, rb+0
+			a := 55
+			b := 0
+			v := a + b
+			t := rb + 0
+			if err := mem.Store(t, v); err != nil {
+				return false
+			}
+			if t >= 0 && t < len(fibCode) && fibCode[t] {
+				ip = 50
+				steps++
+				return true
+			}
+			ip = 50
+			steps++
+			fallthrough
+		case 50: // ARB #1
+			a := 1
+			rb += a
+			ip = 52
+			steps++
+			fallthrough
+		case 52: // JT  #1, #24
+			b := 24
+			ip = b
+			steps++
+			continue
+		case 55: // ARB #-1
+			a := -1
+			rb += a
+			ip = 57
+			steps++
+			fallthrough
+		case 57: // ADD [96], #0, rb+0
+			a, err := mem.Load(96)
+			if err != nil {
+				return false
+			}
+			b := 0
+			v := a + b
+			t := rb + 0
+			if err := mem.Store(t, v); err != nil {
+				return false
+			}
+			if t >= 0 && t < len(fibCode) && fibCode[t] {
+				ip = 61
+				steps++
+				return true
+			}
+			ip = 61
+			steps++
+			fallthrough
+		case 61: // ARB #1
+			a := 1
+			rb += a
+			ip = 63
+			steps++
+			fallthrough
+		case 63: // ADD rb-3, #-2, rb+0
+			a, err := mem.Load(rb + -3)
+			if err != nil {
+				return false
+			}
+			b := -2
+			v := a + b
+			t := rb + 0
+			if err := mem.Store(t, v); err != nil {
+				return false
+			}
+			if t >= 0 && t < len(fibCode) && fibCode[t] {
+				ip = 67
+				steps++
+				return true
+			}
+			ip = 67
+			steps++
+			fallthrough
+		case 67: // ARB #1
+			a := 1
+			rb += a
+			ip = 69
+			steps++
+			fallthrough
+		case 69: // ADD #78, #0, rb+0
+			a := 78
+			b := 0
+			v := a + b
+			t := rb + 0
+			if err := mem.Store(t, v); err != nil {
+				return false
+			}
+			if t >= 0 && t < len(fibCode) && fibCode[t] {
+				ip = 73
+				steps++
+				return true
+			}
+			ip = 73
+			steps++
+			fallthrough
+		case 73: // ARB #1
+			a := 1
+			rb += a
+			ip = 75
+			steps++
+			fallthrough
+		case 75: // JT  #1, #24
+			b := 24
+			ip = b
+			steps++
+			continue
+		case 78: // ARB #-1
+			a := -1
+			rb += a
+			ip = 80
+			steps++
+			fallthrough
+		case 80: // ARB #-1
+			a := -1
+			rb += a
+			ip = 82
+			steps++
+			fallthrough
+		case 82: // ADD rb+0, #0, [97]
+			a, err := mem.Load(rb + 0)
+			if err != nil {
+				return false
+			}
+			b := 0
+			v := a + b
+			if err := mem.Store(97, v); err != nil {
+				return false
+			}
+			ip = 86
+			steps++
+			fallthrough
+		case 86: // ADD [97], [96], [96]
+			a, err := mem.Load(97)
+			if err != nil {
+				return false
+			}
+			b, err := mem.Load(96)
+			if err != nil {
+				return false
+			}
+			v := a + b
+			if err := mem.Store(96, v); err != nil {
+				return false
+			}
+			ip = 90
+			steps++
+			fallthrough
+		case 90: // ARB #-1
+			a := -1
+			rb += a
+			ip = 92
+			steps++
+			fallthrough
+		case 92: // JT  #1, rb+0
+			b, err := mem.Load(rb + 0)
+			if err != nil {
+				return false
+			}
+			ip = b
+			steps++
+			continue
+		default:
+			return false
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/jingqiuELE/advent_code_2019/intcode"
+	flag "github.com/spf13/pflag"
+)
+
+func main() {
+	var dataFile string
+	var dotFile string
+
+	flag.StringVarP(&dataFile, "data file name", "f", "", "program image, or assembler source ending in .asm")
+	flag.StringVarP(&dotFile, "dot file name", "d", "", "write the graph in Graphviz DOT format, - for stdout")
+	flag.Parse()
+
+	var program []int
+	var err error
+	if strings.HasSuffix(dataFile, ".asm") {
+		var src []byte
+		src, err = ioutil.ReadFile(dataFile)
+		if err == nil {
+			program, err = intcode.Assemble(string(src))
+		}
+	} else {
+		program, err = intcode.BuildList(dataFile)
+	}
+	if err != nil {
+		log.Fatal("Failed to get program from input file!", err)
+	}
+
+	g := intcode.Analyze(program)
+
+	if dotFile == "-" {
+		if err := g.WriteDot(os.Stdout); err != nil {
+			log.Fatal("Failed to write graph!", err)
+		}
+		return
+	}
+	if dotFile != "" {
+		f, err := os.Create(dotFile)
+		if err != nil {
+			log.Fatal("Failed to create dot file!", err)
+		}
+		err = g.WriteDot(f)
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			log.Fatal("Failed to write graph!", err)
+		}
+	}
+
+	for _, addr := range g.Addrs() {
+		b := g.Blocks[addr]
+		fmt.Printf("block %d-%d, %d instructions -> %v", b.Start, b.End-1, len(b.Insts), b.Succs)
+		switch {
+		case b.Return:
+			fmt.Printf(" return")
+		case b.Indirect:
+			fmt.Printf(" indirect")
+		}
+		fmt.Println()
+	}
+	for _, addr := range g.Indirect {
+		fmt.Printf("indirect jump at %d\n", addr)
+	}
+	for _, w := range g.Writes {
+		fmt.Printf("self-modifying write at %d to %d\n", w.Addr, w.Target)
+	}
+	for _, c := range g.Calls {
+		fmt.Printf("call at %d to %d, returning to %d, frame %d\n", c.Addr, c.Target, c.Return, c.Frame)
+	}
+}
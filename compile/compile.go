@@ -0,0 +1,52 @@
+package main
+
+import (
+	"io/ioutil"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/jingqiuELE/advent_code_2019/intcode"
+	flag "github.com/spf13/pflag"
+)
+
+func main() {
+	var dataFile string
+	var outputFile string
+	var pkg string
+	var name string
+
+	flag.StringVarP(&dataFile, "data file name", "f", "", "program image, or assembler source ending in .asm")
+	flag.StringVarP(&outputFile, "output file name", "o", "", "defaults to stdout")
+	flag.StringVarP(&pkg, "package", "p", "main", "package of the generated code")
+	flag.StringVarP(&name, "name", "n", "program", "name of the generated function")
+	flag.Parse()
+
+	var program []int
+	var err error
+	if strings.HasSuffix(dataFile, ".asm") {
+		var src []byte
+		src, err = ioutil.ReadFile(dataFile)
+		if err == nil {
+			program, err = intcode.Assemble(string(src))
+		}
+	} else {
+		program, err = intcode.BuildList(dataFile)
+	}
+	if err != nil {
+		log.Fatal("Failed to get program from input file!", err)
+	}
+
+	src, err := intcode.Compile(program, pkg, name)
+	if err != nil {
+		log.Fatal("Failed to compile!", err)
+	}
+
+	if outputFile == "" {
+		os.Stdout.Write(src)
+		return
+	}
+	if err := ioutil.WriteFile(outputFile, src, 0644); err != nil {
+		log.Fatal("Failed to write output file!", err)
+	}
+}
@@ -1,35 +1,14 @@
 package main
 
 import (
-	"bufio"
+	"context"
 	"fmt"
 	"log"
-	"os"
-	"strconv"
-	"strings"
 
+	"github.com/jingqiuELE/advent_code_2019/intcode"
 	flag "github.com/spf13/pflag"
 )
 
-const (
-	ADD           = 1
-	MULTIPLY      = 2
-	STORE         = 3
-	LOAD          = 4
-	JUMP_IF_TRUE  = 5
-	JUMP_IF_FALSE = 6
-	LESS_THAN     = 7
-	EQUALS        = 8
-	RELATIVE_BASE = 9
-	HALT          = 99
-)
-
-const (
-	POSITION  = 0
-	IMMEDIATE = 1
-	RELATIVE  = 2
-)
-
 const (
 	UP = iota
 	LEFT
@@ -54,7 +33,7 @@ func main() {
 	flag.StringVarP(&dataFile, "data file name", "f", "", "")
 	flag.Parse()
 
-	program, err := buildList(dataFile)
+	program, err := intcode.BuildList(dataFile)
 	if err != nil {
 		log.Fatal("Failed to get program from input file!", err)
 	}
@@ -72,32 +51,31 @@ func main() {
 	facing := UP
 	panels[pos.Y][pos.X] = 1
 
-	input := make(chan int, 1)
-	output := make(chan int, 2)
-	go runProgram(program, input, output)
-	input <- 1
-
-	done := false
-	for done == false {
-		select {
-		case color, ok := <-output:
-			if ok == false {
-				done = true
-				break
-			}
-
-			direction, ok := <-output
-			if ok == false {
-				done = true
-				break
-			}
-			fmt.Printf("Color=%v, Dir=%v\n", color, direction)
-			panels[pos.Y][pos.X] = color
-			painted[pos] = true
-			pos, facing = calculateNewPos(pos, facing, direction)
-			fmt.Println("NewPos:", pos)
-			input <- panels[pos.Y][pos.X]
+	// The camera reports the colour under the robot, and every pair of
+	// outputs paints the panel and moves the robot.
+	camera := intcode.InputFunc(func() (int, error) {
+		return panels[pos.Y][pos.X], nil
+	})
+	var pending []int
+	motor := intcode.OutputFunc(func(v int) error {
+		pending = append(pending, v)
+		if len(pending) < 2 {
+			return nil
 		}
+		color, direction := pending[0], pending[1]
+		pending = pending[:0]
+
+		fmt.Printf("Color=%v, Dir=%v\n", color, direction)
+		panels[pos.Y][pos.X] = color
+		painted[pos] = true
+		pos, facing = calculateNewPos(pos, facing, direction)
+		fmt.Println("NewPos:", pos)
+		return nil
+	})
+
+	robot := intcode.NewMachine(program)
+	if err := robot.RunIO(context.Background(), camera, motor); err != nil {
+		log.Fatal("Failed to run the robot!", err)
 	}
 
 	count := 0
@@ -164,151 +142,3 @@ func calculateNewPos(pos Position, facing int, dir int) (Position, int) {
 
 	return npos, nfacing
 }
-
-func runProgram(program []int, input chan int, output chan int) {
-	p := make([]int, len(program)+1024*4)
-	copy(p, program)
-
-	relative_base := 0
-	i := 0
-	for i < len(p) {
-		opcode, pmode := parseCode(p[i])
-		switch opcode {
-		case ADD:
-			param0 := loadParam(p[i+1], pmode[0], relative_base, p)
-			param1 := loadParam(p[i+2], pmode[1], relative_base, p)
-			rpos := loadPos(p[i+3], pmode[2], relative_base)
-			p[rpos] = param0 + param1
-			i += 4
-		case MULTIPLY:
-			param0 := loadParam(p[i+1], pmode[0], relative_base, p)
-			param1 := loadParam(p[i+2], pmode[1], relative_base, p)
-			rpos := loadPos(p[i+3], pmode[2], relative_base)
-			p[rpos] = param0 * param1
-			i += 4
-		case STORE:
-			rpos := loadPos(p[i+1], pmode[0], relative_base)
-			p[rpos] = <-input
-			i += 2
-		case LOAD:
-			param0 := loadParam(p[i+1], pmode[0], relative_base, p)
-			output <- param0
-			i += 2
-		case JUMP_IF_TRUE:
-			param0 := loadParam(p[i+1], pmode[0], relative_base, p)
-			param1 := loadParam(p[i+2], pmode[1], relative_base, p)
-			if param0 != int(0) {
-				i = int(param1)
-			} else {
-				i += 3
-			}
-		case JUMP_IF_FALSE:
-			param0 := loadParam(p[i+1], pmode[0], relative_base, p)
-			param1 := loadParam(p[i+2], pmode[1], relative_base, p)
-			if param0 == int(0) {
-				i = int(param1)
-			} else {
-				i += 3
-			}
-		case LESS_THAN:
-			param0 := loadParam(p[i+1], pmode[0], relative_base, p)
-			param1 := loadParam(p[i+2], pmode[1], relative_base, p)
-			rpos := loadPos(p[i+3], pmode[2], relative_base)
-			if param0 < param1 {
-				p[rpos] = 1
-			} else {
-				p[rpos] = 0
-			}
-			i += 4
-		case EQUALS:
-			param0 := loadParam(p[i+1], pmode[0], relative_base, p)
-			param1 := loadParam(p[i+2], pmode[1], relative_base, p)
-			rpos := loadPos(p[i+3], pmode[2], relative_base)
-			if param0 == param1 {
-				p[rpos] = 1
-			} else {
-				p[rpos] = 0
-			}
-			i += 4
-		case RELATIVE_BASE:
-			param0 := loadParam(p[i+1], pmode[0], relative_base, p)
-			relative_base += param0
-			i += 2
-		case HALT:
-			close(output)
-			return
-		default:
-			log.Fatal("unrecognized opcode=", opcode)
-		}
-	}
-}
-
-func parseCode(code int) (opcode int, pmode []int) {
-	var ps int
-
-	opcode = code % 100
-
-	pmode = make([]int, 3)
-
-	ps = (code - opcode) / 100
-	for i := 0; ps > 0; i++ {
-		digit := ps % 10
-		pmode[i] = digit
-		ps = (ps - digit) / 10
-	}
-	return opcode, pmode
-}
-
-func loadParam(data int, mode int, base int, p []int) int {
-	switch mode {
-	case IMMEDIATE:
-		return data
-	case POSITION:
-		return p[data]
-	case RELATIVE:
-		return p[base+data]
-	}
-	log.Fatal("Shouldn't get here")
-	return -1
-}
-
-func loadPos(pos int, mode int, base int) int {
-	switch mode {
-	case POSITION:
-		return pos
-	case RELATIVE:
-		return base + pos
-	}
-	log.Fatal("Shouldn't get here")
-	return -1
-}
-
-func buildList(dataFile string) ([]int, error) {
-	var program []int
-
-	f, err := os.Open(dataFile)
-	if err != nil {
-		return nil, err
-	}
-
-	defer f.Close()
-
-	buff := bufio.NewReader(f)
-	for {
-		line, err := buff.ReadString('\n')
-		if err != nil {
-			break
-		}
-		line = strings.TrimSuffix(line, "\n")
-		lists := strings.Split(line, ",")
-		for _, l := range lists {
-			num, err := strconv.ParseInt(l, 10, 64)
-			if err != nil {
-				log.Fatal(err)
-				os.Exit(2)
-			}
-			program = append(program, int(num))
-		}
-	}
-	return program, err
-}
@@ -0,0 +1,220 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"github.com/jingqiuELE/advent_code_2019/intcode"
+)
+
+// Tile is what the arcade draws at a position.
+type Tile int
+
+const (
+	EmptyTile Tile = iota
+	Wall
+	Block
+	Paddle
+	Ball
+)
+
+// Arcade runs the game in lock-step with the machine. Draw instructions are
+// applied as the machine outputs them, and each time the game reads the
+// joystick the frame drawn so far is complete: the player is asked for
+// exactly one move and the machine goes on. Nothing depends on timing, so a
+// game played by the same player always ends the same way.
+type Arcade struct {
+	Screen map[Position]Tile
+	Score  int
+	Ball   Position
+	Paddle Position
+	// Blocks counts the blocks left on the screen.
+	Blocks int
+	// Width and Height bound the positions drawn so far.
+	Width  int
+	Height int
+	// Moves counts the joystick reads.
+	Moves int
+	// Partial is set for a game resumed without its screen. Blocks then
+	// only counts the blocks drawn since, and the game cannot tell whether
+	// it was won.
+	Partial bool
+
+	// Player returns the joystick position, -1 for left, 0 for neutral and
+	// 1 for right, each time the game reads it.
+	Player func(a *Arcade) int
+	// OnFrame, if set, is called with every complete frame before the
+	// player moves.
+	OnFrame func(a *Arcade)
+
+	machine *intcode.Machine
+	draw    []int
+}
+
+// NewArcade returns an arcade running the game loaded in m.
+func NewArcade(m *intcode.Machine) *Arcade {
+	a := Arcade{
+		Screen:  make(map[Position]Tile),
+		Player:  Follow,
+		machine: m,
+	}
+	return &a
+}
+
+// Run plays until the game halts, the machine faults or ctx is done.
+func (a *Arcade) Run(ctx context.Context) error {
+	return a.machine.RunIO(ctx, intcode.InputFunc(a.joystick), intcode.OutputFunc(a.output))
+}
+
+// Won reports whether every block has been broken.
+func (a *Arcade) Won() bool {
+	return a.machine.Halted && !a.Partial && a.Blocks == 0
+}
+
+func (a *Arcade) joystick() (int, error) {
+	if a.OnFrame != nil {
+		a.OnFrame(a)
+	}
+	a.Moves++
+	return a.Player(a), nil
+}
+
+func (a *Arcade) output(value int) error {
+	a.draw = append(a.draw, value)
+	if len(a.draw) < 3 {
+		return nil
+	}
+	x, y, id := a.draw[0], a.draw[1], a.draw[2]
+	a.draw = a.draw[:0]
+
+	if x == -1 && y == 0 {
+		a.Score = id
+		return nil
+	}
+	if x < 0 || y < 0 {
+		return fmt.Errorf("draw outside the screen at (%d, %d)", x, y)
+	}
+	pos := Position{X: x, Y: y}
+	if a.Screen[pos] == Block {
+		a.Blocks--
+	}
+	tile := Tile(id)
+	switch tile {
+	case Block:
+		a.Blocks++
+	case Paddle:
+		a.Paddle = pos
+	case Ball:
+		a.Ball = pos
+	}
+	a.Screen[pos] = tile
+	if x >= a.Width {
+		a.Width = x + 1
+	}
+	if y >= a.Height {
+		a.Height = y + 1
+	}
+	return nil
+}
+
+// screenFile is the on-disk layout of what the arcade has drawn.
+type screenFile struct {
+	Tiles  [][3]int `json:"tiles"`
+	Score  int      `json:"score"`
+	Ball   Position `json:"ball"`
+	Paddle Position `json:"paddle"`
+	Blocks int      `json:"blocks"`
+	Width  int      `json:"width"`
+	Height int      `json:"height"`
+	Moves  int      `json:"moves"`
+	Draw   []int    `json:"draw,omitempty"`
+}
+
+// SaveScreen writes the screen, score and counters to path, replacing the
+// file only once it is completely written. The machine is saved separately
+// with a snapshot.
+func (a *Arcade) SaveScreen(path string) error {
+	f := screenFile{
+		Score:  a.Score,
+		Ball:   a.Ball,
+		Paddle: a.Paddle,
+		Blocks: a.Blocks,
+		Width:  a.Width,
+		Height: a.Height,
+		Moves:  a.Moves,
+		Draw:   a.draw,
+	}
+	for pos, tile := range a.Screen {
+		f.Tiles = append(f.Tiles, [3]int{pos.X, pos.Y, int(tile)})
+	}
+	data, err := json.Marshal(&f)
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// LoadScreen restores what SaveScreen wrote to path.
+func (a *Arcade) LoadScreen(path string) error {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var f screenFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return err
+	}
+	a.Screen = make(map[Position]Tile)
+	for _, t := range f.Tiles {
+		a.Screen[Position{X: t[0], Y: t[1]}] = Tile(t[2])
+	}
+	a.Score = f.Score
+	a.Ball = f.Ball
+	a.Paddle = f.Paddle
+	a.Blocks = f.Blocks
+	a.Width = f.Width
+	a.Height = f.Height
+	a.Moves = f.Moves
+	a.draw = f.Draw
+	a.Partial = false
+	return nil
+}
+
+// Follow is the automatic player: it keeps the paddle under the ball.
+func Follow(a *Arcade) int {
+	switch {
+	case a.Paddle.X < a.Ball.X:
+		return 1
+	case a.Paddle.X > a.Ball.X:
+		return -1
+	}
+	return 0
+}
+
+var tileChars = map[Tile]byte{
+	EmptyTile: ' ',
+	Wall:      '=',
+	Block:     'X',
+	Paddle:    '_',
+	Ball:      'O',
+}
+
+// Print writes the screen and the score.
+func (a *Arcade) Print(w io.Writer) {
+	line := make([]byte, a.Width)
+	for y := 0; y < a.Height; y++ {
+		for x := range line {
+			line[x] = tileChars[a.Screen[Position{X: x, Y: y}]]
+		}
+		fmt.Fprintf(w, "%s\n", line)
+	}
+	fmt.Fprintf(w, "score=%d blocks=%d\n", a.Score, a.Blocks)
+}
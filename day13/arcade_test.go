@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/jingqiuELE/advent_code_2019/intcode"
+)
+
+// breakout is a tiny game: the ball bounces between x=1 and x=3 over the
+// paddle, and every time the paddle catches it the block above it breaks.
+// It is won with a score of 3.
+const breakout = `
+        MUL  [t], [t], [t]      ; cell 0 is the quarters slot
+        OUT  #0
+        OUT  #0
+        OUT  #1
+        OUT  #4
+        OUT  #0
+        OUT  #1
+        OUT  #1
+        OUT  #1
+        OUT  #2
+        OUT  #2
+        OUT  #1
+        OUT  #2
+        OUT  #3
+        OUT  #1
+        OUT  #2
+tick:   OUT  [bx]               ; erase the ball
+        OUT  #2
+        OUT  #0
+        ADD  [bx], [dx], [bx]
+        EQ   [bx], #3, [t]
+        JF   [t], #nr
+        MUL  [dx], #-1, [dx]
+nr:     EQ   [bx], #1, [t]
+        JF   [t], #nl
+        MUL  [dx], #-1, [dx]
+nl:     OUT  [bx]               ; draw the ball
+        OUT  #2
+        OUT  #4
+        OUT  [px]               ; erase the paddle
+        OUT  #3
+        OUT  #0
+        IN   [j]
+        ADD  [px], [j], [px]
+        OUT  [px]               ; draw the paddle
+        OUT  #3
+        OUT  #3
+        EQ   [px], [bx], [t]
+        JF   [t], #over
+        ADD  [bx], #blocks, [ld+1]
+ld:     ADD  [0], #0, [t]
+        JF   [t], #tick
+        ADD  [bx], #blocks, [clr+3]
+clr:    ADD  #0, #0, [0]
+        OUT  [bx]               ; erase the block
+        OUT  #1
+        OUT  #0
+        ADD  [score], #1, [score]
+        OUT  #-1
+        OUT  #0
+        OUT  [score]
+        EQ   [score], #3, [t]
+        JF   [t], #tick
+over:   HLT
+bx:     db 2
+dx:     db 1
+px:     db 2
+j:      db 0
+t:      db 0
+score:  db 0
+blocks: db 0, 1, 1, 1, 0
+`
+
+func newGame(t *testing.T) *Arcade {
+	t.Helper()
+	program, err := intcode.Assemble(breakout)
+	if err != nil {
+		t.Fatal(err)
+	}
+	program[0] = 2
+	return NewArcade(intcode.NewMachine(program))
+}
+
+func TestPlay(t *testing.T) {
+	game := newGame(t)
+	if err := game.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if game.Score != 3 || game.Blocks != 0 || !game.Won() {
+		t.Fatalf("score=%d blocks=%d won=%v, want a won game with score 3", game.Score, game.Blocks, game.Won())
+	}
+}
+
+func TestResume(t *testing.T) {
+	stateFile := filepath.Join(t.TempDir(), "breakout.state")
+
+	// Play until the checkpoint at the second joystick read, then stop.
+	game := newGame(t)
+	save := checkpoint(stateFile, 2, game)
+	game.machine.OnInput = func(m *intcode.Machine) {
+		save(m)
+		if game.Moves == 1 {
+			m.MaxSteps = m.Steps + 1
+		}
+	}
+	if err := game.Run(context.Background()); !errors.Is(err, intcode.ErrStepLimit) {
+		t.Fatalf("got %v, want the game stopped after the checkpoint", err)
+	}
+
+	state, err := intcode.LoadSnapshot(stateFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := intcode.NewMachine(nil)
+	m.Restore(state)
+	resumed := NewArcade(m)
+	if err := resumed.LoadScreen(screenPath(stateFile)); err != nil {
+		t.Fatal(err)
+	}
+	if resumed.Moves != 1 || resumed.Blocks == 0 {
+		t.Fatalf("resumed at move %d with %d blocks, want move 1 with blocks left", resumed.Moves, resumed.Blocks)
+	}
+
+	if err := resumed.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if resumed.Score != 3 || resumed.Blocks != 0 || !resumed.Won() {
+		t.Fatalf("score=%d blocks=%d won=%v, want a won game with score 3", resumed.Score, resumed.Blocks, resumed.Won())
+	}
+}
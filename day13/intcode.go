@@ -1,345 +1,127 @@
 package main
 
 import (
-	"bufio"
+	"context"
 	"fmt"
-	"io"
 	"log"
 	"os"
-	"os/exec"
-	"strconv"
-	"strings"
-	"time"
 
+	"github.com/jingqiuELE/advent_code_2019/intcode"
 	flag "github.com/spf13/pflag"
 )
 
-const (
-	ADD           = 1
-	MULTIPLY      = 2
-	STORE         = 3
-	LOAD          = 4
-	JUMP_IF_TRUE  = 5
-	JUMP_IF_FALSE = 6
-	LESS_THAN     = 7
-	EQUALS        = 8
-	RELATIVE_BASE = 9
-	HALT          = 99
-)
-
-const (
-	POSITION  = 0
-	IMMEDIATE = 1
-	RELATIVE  = 2
-)
-
 type Position struct {
 	Y int
 	X int
 }
 
-const N = 50
-
 func main() {
 	var dataFile string
+	var resumeFile string
+	var checkpointFile string
+	var profileFile string
+	var every int
+	var show bool
+	var interactive bool
+	var auto bool
+	var fps int
 
 	flag.StringVarP(&dataFile, "data file name", "f", "", "")
+	flag.StringVarP(&resumeFile, "resume", "r", "", "resume the game saved in this state file")
+	flag.StringVarP(&checkpointFile, "checkpoint", "c", "", "save the game to this state file while playing")
+	flag.IntVarP(&every, "every", "n", 100, "joystick reads between checkpoints")
+	flag.StringVarP(&profileFile, "profile", "p", "", "write an instruction profile to this file")
+	flag.BoolVarP(&show, "show", "s", false, "print every frame")
+	flag.BoolVarP(&interactive, "interactive", "i", false, "play on the terminal")
+	flag.BoolVarP(&auto, "auto", "a", false, "start interactive play with the auto-player in control")
+	flag.IntVarP(&fps, "fps", "F", 20, "frames per second of interactive play")
 	flag.Parse()
 
-	program, err := buildList(dataFile)
-	if err != nil {
-		log.Fatal("Failed to get program from input file!", err)
-	}
-
-	input := make(chan int, 1)
-	output := make(chan int, 3)
-	games := make(chan map[Position]int)
-
-	go runProgram(program, input, output)
-	go playGames(input, games)
-
-	panel := make(map[Position]int)
-	var score int
-
-	tick := time.Tick(10 * time.Millisecond)
-	done := false
-	max_y := 0
-	max_x := 0
-	for done == false {
-		select {
-		case x, ok := <-output:
-			if ok == false {
-				done = true
-				break
-			}
-
-			y, ok := <-output
-			if ok == false {
-				done = true
-				break
-			}
-
-			tile, ok := <-output
-			if ok == false {
-				done = true
-				break
-			}
-
-			if x == -1 && y == 0 {
-				score = tile
-			} else {
-				if y > max_y {
-					max_y = y
-				}
-
-				if x > max_x {
-					max_x = x
-				}
-
-				p := Position{
-					Y: y,
-					X: x,
-				}
-				panel[p] = tile
-			}
-		case <-tick:
-			refreshScreen(panel, max_y, max_x)
-			games <- panel
+	var arcade *intcode.Machine
+	if resumeFile != "" {
+		state, err := intcode.LoadSnapshot(resumeFile)
+		if err != nil {
+			log.Fatal("Failed to load state file!", err)
 		}
-	}
-	fmt.Println("score=", score)
-}
-
-func refreshScreen(panel map[Position]int, max_y int, max_x int) {
-	counter := 0
-	var frame [][]int
-
-	frame = make([][]int, max_y+1)
-	for i, _ := range frame {
-		frame[i] = make([]int, max_x+1)
+		arcade = intcode.NewMachine(nil)
+		arcade.Restore(state)
+	} else {
+		program, err := intcode.BuildList(dataFile)
+		if err != nil {
+			log.Fatal("Failed to get program from input file!", err)
+		}
+		program[0] = 2
+		arcade = intcode.NewMachine(program)
 	}
 
-	for pos, tile := range panel {
-		frame[pos.Y][pos.X] = tile
+	if profileFile != "" {
+		arcade.Profile = intcode.NewProfile()
 	}
 
-	for _, line := range frame {
-		for _, p := range line {
-			switch p {
-			case 0:
-				fmt.Printf(" ")
-			case 1:
-				fmt.Printf("=")
-			case 2:
-				fmt.Printf("X")
-				counter++
-			case 3:
-				fmt.Printf("_")
-			case 4:
-				fmt.Printf("O")
-			}
+	game := NewArcade(arcade)
+	if resumeFile != "" {
+		if err := game.LoadScreen(screenPath(resumeFile)); err != nil {
+			log.Println("Failed to load the screen, blocks are only counted once redrawn!", err)
+			game.Partial = true
 		}
-		fmt.Println("")
 	}
-}
-
-func playGames(control chan int, pchannel chan map[Position]int) {
-	var target Position
-	var padel Position
-
-	for {
-		select {
-		case panel := <-pchannel:
-			for pos, tile := range panel {
-				if tile == 4 {
-					target = pos
-				} else if tile == 3 {
-					padel = pos
-				}
-			}
-		}
-
-		if padel.X < target.X {
-			control <- 1
-		} else if padel.X > target.X {
-			control <- -1
-		} else {
-			control <- 0
-		}
+	if checkpointFile != "" {
+		arcade.OnInput = checkpoint(checkpointFile, every, game)
 	}
-}
-
-func runControl(input chan int) {
-	// disable input buffering
-	exec.Command("stty", "-F", "/dev/tty", "cbreak", "min", "1").Run()
-	// do not display entered characters on the screen
-	exec.Command("stty", "-F", "/dev/tty", "-echo").Run()
-	b := make([]byte, 1)
-	for {
-		_, err := os.Stdin.Read(b)
-		if err == io.EOF {
-			break
-		}
-		fmt.Println("input=", b[0])
-		switch b[0] {
-		case 97:
-			input <- -1
-		case 111:
-			input <- 1
-		default:
-			input <- 0
+	if interactive {
+		err := playInteractive(game, fps, auto)
+		if err != nil {
+			log.Fatal("Failed to run game!", err)
 		}
-	}
-}
-
-func runProgram(program []int, input chan int, output chan int) {
-	p := make([]int, len(program)+1024*4)
-	copy(p, program)
-
-	p[0] = 2
-
-	relative_base := 0
-	i := 0
-	for i < len(p) {
-		opcode, pmode := parseCode(p[i])
-		switch opcode {
-		case ADD:
-			param0 := loadParam(p[i+1], pmode[0], relative_base, p)
-			param1 := loadParam(p[i+2], pmode[1], relative_base, p)
-			rpos := loadPos(p[i+3], pmode[2], relative_base)
-			p[rpos] = param0 + param1
-			i += 4
-		case MULTIPLY:
-			param0 := loadParam(p[i+1], pmode[0], relative_base, p)
-			param1 := loadParam(p[i+2], pmode[1], relative_base, p)
-			rpos := loadPos(p[i+3], pmode[2], relative_base)
-			p[rpos] = param0 * param1
-			i += 4
-		case STORE:
-			rpos := loadPos(p[i+1], pmode[0], relative_base)
-			p[rpos] = <-input
-			fmt.Println("machine got input", p[rpos])
-			i += 2
-		case LOAD:
-			param0 := loadParam(p[i+1], pmode[0], relative_base, p)
-			output <- param0
-			i += 2
-		case JUMP_IF_TRUE:
-			param0 := loadParam(p[i+1], pmode[0], relative_base, p)
-			param1 := loadParam(p[i+2], pmode[1], relative_base, p)
-			if param0 != int(0) {
-				i = int(param1)
-			} else {
-				i += 3
-			}
-		case JUMP_IF_FALSE:
-			param0 := loadParam(p[i+1], pmode[0], relative_base, p)
-			param1 := loadParam(p[i+2], pmode[1], relative_base, p)
-			if param0 == int(0) {
-				i = int(param1)
-			} else {
-				i += 3
-			}
-		case LESS_THAN:
-			param0 := loadParam(p[i+1], pmode[0], relative_base, p)
-			param1 := loadParam(p[i+2], pmode[1], relative_base, p)
-			rpos := loadPos(p[i+3], pmode[2], relative_base)
-			if param0 < param1 {
-				p[rpos] = 1
-			} else {
-				p[rpos] = 0
+	} else {
+		if show {
+			game.OnFrame = func(a *Arcade) {
+				a.Print(os.Stdout)
 			}
-			i += 4
-		case EQUALS:
-			param0 := loadParam(p[i+1], pmode[0], relative_base, p)
-			param1 := loadParam(p[i+2], pmode[1], relative_base, p)
-			rpos := loadPos(p[i+3], pmode[2], relative_base)
-			if param0 == param1 {
-				p[rpos] = 1
-			} else {
-				p[rpos] = 0
-			}
-			i += 4
-		case RELATIVE_BASE:
-			param0 := loadParam(p[i+1], pmode[0], relative_base, p)
-			relative_base += param0
-			i += 2
-		case HALT:
-			fmt.Println("HALT")
-			close(output)
-			return
-		default:
-			log.Fatal("unrecognized opcode=", opcode)
+		}
+		if err := game.Run(context.Background()); err != nil {
+			log.Fatal("Failed to run game!", err)
+		}
+		if show {
+			game.Print(os.Stdout)
 		}
 	}
-}
-
-func parseCode(code int) (opcode int, pmode []int) {
-	var ps int
-
-	opcode = code % 100
-
-	pmode = make([]int, 3)
-
-	ps = (code - opcode) / 100
-	for i := 0; ps > 0; i++ {
-		digit := ps % 10
-		pmode[i] = digit
-		ps = (ps - digit) / 10
+	fmt.Println("score=", game.Score)
+	if !game.Won() && !game.Partial {
+		fmt.Println("blocks left=", game.Blocks)
 	}
-	return opcode, pmode
-}
 
-func loadParam(data int, mode int, base int, p []int) int {
-	switch mode {
-	case IMMEDIATE:
-		return data
-	case POSITION:
-		return p[data]
-	case RELATIVE:
-		return p[base+data]
+	if arcade.Profile != nil {
+		if err := arcade.Profile.Save(profileFile, arcade.Memory.Image()); err != nil {
+			log.Fatal("Failed to write profile!", err)
+		}
 	}
-	log.Fatal("Shouldn't get here")
-	return -1
 }
 
-func loadPos(pos int, mode int, base int) int {
-	switch mode {
-	case POSITION:
-		return pos
-	case RELATIVE:
-		return base + pos
-	}
-	log.Fatal("Shouldn't get here")
-	return -1
+// screenPath is where the screen of the game saved to stateFile is kept.
+func screenPath(stateFile string) string {
+	return stateFile + ".screen"
 }
 
-func buildList(dataFile string) ([]int, error) {
-	var program []int
-
-	f, err := os.Open(dataFile)
-	if err != nil {
-		return nil, err
+// checkpoint saves the machine state every n joystick reads, and next to it
+// the screen of game: the program only redraws what changes, so a resumed
+// game needs the screen to know the blocks left.
+func checkpoint(stateFile string, n int, game *Arcade) func(m *intcode.Machine) {
+	reads := 0
+	if n < 1 {
+		n = 1
 	}
-
-	defer f.Close()
-
-	buff := bufio.NewReader(f)
-	for {
-		line, err := buff.ReadString('\n')
-		if err != nil {
-			break
+	return func(m *intcode.Machine) {
+		reads++
+		if reads%n != 0 {
+			return
 		}
-		line = strings.TrimSuffix(line, "\n")
-		lists := strings.Split(line, ",")
-		for _, l := range lists {
-			num, err := strconv.ParseInt(l, 10, 64)
-			if err != nil {
-				log.Fatal(err)
-				os.Exit(2)
-			}
-			program = append(program, int(num))
+		if err := m.Snapshot().Save(stateFile); err != nil {
+			log.Println("Failed to save checkpoint!", err)
+			return
+		}
+		if err := game.SaveScreen(screenPath(stateFile)); err != nil {
+			log.Println("Failed to save the screen!", err)
 		}
 	}
-	return program, err
 }
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+// terminal is a terminal switched to cbreak mode: keys are delivered as
+// they are pressed, without echo, and signals still work.
+type terminal struct {
+	fd  int
+	old syscall.Termios
+}
+
+func rawTerminal(fd int) (*terminal, error) {
+	t := terminal{
+		fd: fd,
+	}
+	if err := ioctl(fd, syscall.TCGETS, &t.old); err != nil {
+		return nil, err
+	}
+	raw := t.old
+	raw.Lflag &^= syscall.ICANON | syscall.ECHO
+	raw.Cc[syscall.VMIN] = 1
+	raw.Cc[syscall.VTIME] = 0
+	if err := ioctl(fd, syscall.TCSETS, &raw); err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// restore puts the terminal back the way it was.
+func (t *terminal) restore() error {
+	return ioctl(t.fd, syscall.TCSETS, &t.old)
+}
+
+func ioctl(fd int, req uintptr, termios *syscall.Termios) error {
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, uintptr(fd), req, uintptr(unsafe.Pointer(termios)))
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
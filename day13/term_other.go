@@ -0,0 +1,16 @@
+//go:build !linux
+// +build !linux
+
+package main
+
+import "errors"
+
+type terminal struct{}
+
+func rawTerminal(fd int) (*terminal, error) {
+	return nil, errors.New("interactive mode needs a linux terminal")
+}
+
+func (t *terminal) restore() error {
+	return nil
+}
@@ -0,0 +1,226 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// Keys of the interactive mode. The joystick keys are those of the original
+// control, a for left and o for right; the arrow keys are mapped to them.
+const (
+	keyLeft   = 'a'
+	keyRight  = 'o'
+	keyAuto   = 'p'
+	keyFaster = '+'
+	keySlower = '-'
+	keyQuit   = 'q'
+)
+
+const help = "a/o or arrows: move  p: auto-player  +/-: speed  q: quit"
+
+// The frame time stays between these.
+const (
+	minDelay = time.Millisecond
+	maxDelay = time.Second
+)
+
+// ui draws the arcade on the alternate screen of a terminal and reads the
+// joystick from the keyboard. Only the tiles that changed since the last
+// frame are redrawn.
+type ui struct {
+	out   *bufio.Writer
+	keys  <-chan byte
+	drawn map[Position]Tile
+	delay time.Duration
+	auto  bool
+	quit  func()
+}
+
+func newUI(out io.Writer, keys <-chan byte, fps int, auto bool, quit func()) *ui {
+	if fps < 1 {
+		fps = 1
+	}
+	u := ui{
+		out:   bufio.NewWriter(out),
+		keys:  keys,
+		drawn: make(map[Position]Tile),
+		delay: time.Second / time.Duration(fps),
+		auto:  auto,
+		quit:  quit,
+	}
+	return &u
+}
+
+// open switches to the alternate screen and hides the cursor.
+func (u *ui) open() {
+	u.out.WriteString("\x1b[?1049h\x1b[?25l\x1b[2J")
+	u.out.Flush()
+}
+
+// close shows the cursor and returns to the normal screen.
+func (u *ui) close() {
+	u.out.WriteString("\x1b[?25h\x1b[?1049l")
+	u.out.Flush()
+}
+
+// frame draws a complete frame and waits out the frame time.
+func (u *ui) frame(a *Arcade) {
+	u.draw(a, "")
+	time.Sleep(u.delay)
+}
+
+// draw updates the changed tiles and the status lines.
+func (u *ui) draw(a *Arcade, message string) {
+	for pos, tile := range a.Screen {
+		if old, ok := u.drawn[pos]; ok && old == tile {
+			continue
+		}
+		u.drawn[pos] = tile
+		fmt.Fprintf(u.out, "\x1b[%d;%dH%c", pos.Y+1, pos.X+1, tileChars[tile])
+	}
+
+	player := "you"
+	if u.auto {
+		player = "auto"
+	}
+	blocks := fmt.Sprint(a.Blocks)
+	if a.Partial {
+		blocks = "?"
+	}
+	fmt.Fprintf(u.out, "\x1b[%d;1H\x1b[Kscore %d  blocks %s  speed %d fps  player %s",
+		a.Height+2, a.Score, blocks, time.Second/u.delay, player)
+	if message == "" {
+		message = help
+	}
+	fmt.Fprintf(u.out, "\x1b[%d;1H\x1b[K%s", a.Height+3, message)
+	u.out.Flush()
+}
+
+// player handles the keys pressed since the last joystick read and returns
+// the next move: that of the auto-player while it is in control, otherwise
+// the last direction key, or neutral if none was pressed.
+func (u *ui) player(a *Arcade) int {
+	move := 0
+	for pending := true; pending; {
+		select {
+		case k, ok := <-u.keys:
+			if !ok {
+				u.quit()
+				return 0
+			}
+			switch k {
+			case keyLeft:
+				move = -1
+			case keyRight:
+				move = 1
+			case keyAuto:
+				u.auto = !u.auto
+			case keyFaster:
+				if u.delay /= 2; u.delay < minDelay {
+					u.delay = minDelay
+				}
+			case keySlower:
+				if u.delay *= 2; u.delay > maxDelay {
+					u.delay = maxDelay
+				}
+			case keyQuit:
+				u.quit()
+			default:
+				move = 0
+			}
+		default:
+			pending = false
+		}
+	}
+	if u.auto {
+		return Follow(a)
+	}
+	return move
+}
+
+// readKeys sends the bytes read from r to keys, translating the left and
+// right arrow keys, until r fails.
+func readKeys(r io.Reader, keys chan<- byte) {
+	defer close(keys)
+	br := bufio.NewReader(r)
+	for {
+		b, err := br.ReadByte()
+		if err != nil {
+			return
+		}
+		if b == 0x1b {
+			if next, err := br.ReadByte(); err != nil || next != '[' {
+				continue
+			}
+			arrow, err := br.ReadByte()
+			if err != nil {
+				return
+			}
+			switch arrow {
+			case 'D':
+				b = keyLeft
+			case 'C':
+				b = keyRight
+			default:
+				continue
+			}
+		}
+		keys <- b
+	}
+}
+
+// playInteractive runs game on the terminal until it ends or the player
+// quits.
+func playInteractive(game *Arcade, fps int, auto bool) error {
+	term, err := rawTerminal(int(os.Stdin.Fd()))
+	if err != nil {
+		return err
+	}
+	defer term.restore()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(sig)
+	go func() {
+		select {
+		case <-sig:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	keys := make(chan byte, 16)
+	go readKeys(os.Stdin, keys)
+
+	u := newUI(os.Stdout, keys, fps, auto, cancel)
+	u.open()
+	game.OnFrame = u.frame
+	game.Player = u.player
+	err = game.Run(ctx)
+	if ctx.Err() == nil {
+		message := "game over, press any key"
+		if game.Won() {
+			message = "all blocks broken, press any key"
+		}
+		u.draw(game, message)
+		select {
+		case <-keys:
+		case <-ctx.Done():
+		}
+	}
+	u.close()
+
+	if errors.Is(err, context.Canceled) {
+		return nil
+	}
+	return err
+}
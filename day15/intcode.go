@@ -1,35 +1,14 @@
 package main
 
 import (
-	"bufio"
 	"fmt"
 	"log"
 	"os"
-	"strconv"
-	"strings"
 
+	"github.com/jingqiuELE/advent_code_2019/intcode"
 	flag "github.com/spf13/pflag"
 )
 
-const (
-	ADD           = 1
-	MULTIPLY      = 2
-	STORE         = 3
-	LOAD          = 4
-	JUMP_IF_TRUE  = 5
-	JUMP_IF_FALSE = 6
-	LESS_THAN     = 7
-	EQUALS        = 8
-	RELATIVE_BASE = 9
-	HALT          = 99
-)
-
-const (
-	POSITION  = 0
-	IMMEDIATE = 1
-	RELATIVE  = 2
-)
-
 const (
 	NORTH = iota + 1
 	SOUTH
@@ -51,6 +30,7 @@ type Vertex struct {
 	Visited    bool
 	Property   int
 	Neighbours []Position
+	Droid      *intcode.Machine
 }
 
 type Position struct {
@@ -81,20 +61,17 @@ func NewVertex(pos Position) *Vertex {
 
 func main() {
 	var dataFile string
+	var debug bool
 
 	flag.StringVarP(&dataFile, "data file name", "f", "", "")
+	flag.BoolVarP(&debug, "debug", "d", false, "run the root droid under the debugger before exploring")
 	flag.Parse()
 
-	program, err := buildList(dataFile)
+	program, err := intcode.BuildList(dataFile)
 	if err != nil {
 		log.Fatal("Failed to get program from input file!", err)
 	}
 
-	control := make(chan int, 1)
-	output := make(chan int, 1)
-
-	go runProgram(program, control, output)
-
 	vmap := make(map[Position]*Vertex)
 	pos := Position{
 		X: 0,
@@ -103,7 +80,16 @@ func main() {
 	start := NewVertex(pos)
 	vmap[pos] = start
 	start.Visited = true
-	BFS(start, vmap, control, output)
+	start.Droid = intcode.NewMachine(program)
+	if debug {
+		// Every probe clones the root droid, so changes made in the
+		// debugger carry over to the whole exploration.
+		d := intcode.NewDebugger(start.Droid, os.Stdin, os.Stderr)
+		if err := d.Session(); err != nil {
+			log.Fatal("Failed to debug the droid!", err)
+		}
+	}
+	BFS(start, vmap)
 
 	var oxgen *Vertex
 	for _, v := range vmap {
@@ -200,7 +186,9 @@ func spreadCount(start *Vertex, vmap map[Position]*Vertex) int {
 	return count
 }
 
-func BFS(current *Vertex, vmap map[Position]*Vertex, control chan int, output chan int) {
+// BFS explores the maze from current. Instead of walking the droid back after
+// every probe, each probe runs on a clone of the droid standing at current.
+func BFS(current *Vertex, vmap map[Position]*Vertex) {
 	var queue []*Vertex
 	var n *Vertex
 
@@ -216,8 +204,12 @@ func BFS(current *Vertex, vmap map[Position]*Vertex, control chan int, output ch
 			n.Visited = true
 			n.MinPath = current.MinPath + 1
 			n.Direction = i + 1
-			control <- i + 1
-			feedback := <-output
+			droid := current.Droid.Clone()
+			droid.Feed(i + 1)
+			feedback, err := droid.NextOutput()
+			if err != nil {
+				log.Fatal("droid failed: ", err)
+			}
 			switch feedback {
 			case 0:
 				fmt.Printf("hit wall:%v,", n.Pos)
@@ -225,190 +217,19 @@ func BFS(current *Vertex, vmap map[Position]*Vertex, control chan int, output ch
 			case 1:
 				fmt.Printf("add %v,", n.Pos)
 				n.Property = NORMAL
+				n.Droid = droid
 				queue = append(queue, n)
-				control <- oppositeDirection(i + 1)
-				<-output
 			case 2:
 				fmt.Printf("add %v,", n.Pos)
 				n.Property = OXGEN_ROOM
+				n.Droid = droid
 				queue = append(queue, n)
-				control <- oppositeDirection(i + 1)
-				<-output
 			}
 		}
 	}
 
 	fmt.Println("")
 	for _, q := range queue {
-		control <- q.Direction
-		<-output
-		BFS(q, vmap, control, output)
-		control <- oppositeDirection(q.Direction)
-		<-output
-	}
-}
-
-func oppositeDirection(dir int) int {
-	switch dir {
-	case NORTH:
-		return SOUTH
-	case SOUTH:
-		return NORTH
-	case WEST:
-		return EAST
-	case EAST:
-		return WEST
-	default:
-		log.Fatal("unable to handle", dir)
-	}
-	return -1
-}
-
-func runProgram(program []int, input chan int, output chan int) {
-	p := make([]int, len(program)+1024*4)
-	copy(p, program)
-
-	relative_base := 0
-	i := 0
-	for i < len(p) {
-		opcode, pmode := parseCode(p[i])
-		switch opcode {
-		case ADD:
-			param0 := loadParam(p[i+1], pmode[0], relative_base, p)
-			param1 := loadParam(p[i+2], pmode[1], relative_base, p)
-			rpos := loadPos(p[i+3], pmode[2], relative_base)
-			p[rpos] = param0 + param1
-			i += 4
-		case MULTIPLY:
-			param0 := loadParam(p[i+1], pmode[0], relative_base, p)
-			param1 := loadParam(p[i+2], pmode[1], relative_base, p)
-			rpos := loadPos(p[i+3], pmode[2], relative_base)
-			p[rpos] = param0 * param1
-			i += 4
-		case STORE:
-			rpos := loadPos(p[i+1], pmode[0], relative_base)
-			p[rpos] = <-input
-			i += 2
-		case LOAD:
-			param0 := loadParam(p[i+1], pmode[0], relative_base, p)
-			output <- param0
-			i += 2
-		case JUMP_IF_TRUE:
-			param0 := loadParam(p[i+1], pmode[0], relative_base, p)
-			param1 := loadParam(p[i+2], pmode[1], relative_base, p)
-			if param0 != int(0) {
-				i = int(param1)
-			} else {
-				i += 3
-			}
-		case JUMP_IF_FALSE:
-			param0 := loadParam(p[i+1], pmode[0], relative_base, p)
-			param1 := loadParam(p[i+2], pmode[1], relative_base, p)
-			if param0 == int(0) {
-				i = int(param1)
-			} else {
-				i += 3
-			}
-		case LESS_THAN:
-			param0 := loadParam(p[i+1], pmode[0], relative_base, p)
-			param1 := loadParam(p[i+2], pmode[1], relative_base, p)
-			rpos := loadPos(p[i+3], pmode[2], relative_base)
-			if param0 < param1 {
-				p[rpos] = 1
-			} else {
-				p[rpos] = 0
-			}
-			i += 4
-		case EQUALS:
-			param0 := loadParam(p[i+1], pmode[0], relative_base, p)
-			param1 := loadParam(p[i+2], pmode[1], relative_base, p)
-			rpos := loadPos(p[i+3], pmode[2], relative_base)
-			if param0 == param1 {
-				p[rpos] = 1
-			} else {
-				p[rpos] = 0
-			}
-			i += 4
-		case RELATIVE_BASE:
-			param0 := loadParam(p[i+1], pmode[0], relative_base, p)
-			relative_base += param0
-			i += 2
-		case HALT:
-			fmt.Println("HALT")
-			close(output)
-			return
-		default:
-			log.Fatalf("unrecognized opcode=%v at %v\n", opcode, i)
-		}
-	}
-}
-
-func parseCode(code int) (opcode int, pmode []int) {
-	var ps int
-
-	opcode = code % 100
-
-	pmode = make([]int, 3)
-
-	ps = (code - opcode) / 100
-	for i := 0; ps > 0; i++ {
-		digit := ps % 10
-		pmode[i] = digit
-		ps = (ps - digit) / 10
-	}
-	return opcode, pmode
-}
-
-func loadParam(data int, mode int, base int, p []int) int {
-	switch mode {
-	case IMMEDIATE:
-		return data
-	case POSITION:
-		return p[data]
-	case RELATIVE:
-		return p[base+data]
-	}
-	log.Fatal("Shouldn't get here")
-	return -1
-}
-
-func loadPos(pos int, mode int, base int) int {
-	switch mode {
-	case POSITION:
-		return pos
-	case RELATIVE:
-		return base + pos
-	}
-	log.Fatal("Shouldn't get here")
-	return -1
-}
-
-func buildList(dataFile string) ([]int, error) {
-	var program []int
-
-	f, err := os.Open(dataFile)
-	if err != nil {
-		return nil, err
-	}
-
-	defer f.Close()
-
-	buff := bufio.NewReader(f)
-	for {
-		line, err := buff.ReadString('\n')
-		if err != nil {
-			break
-		}
-		line = strings.TrimSuffix(line, "\n")
-		lists := strings.Split(line, ",")
-		for _, l := range lists {
-			num, err := strconv.ParseInt(l, 10, 64)
-			if err != nil {
-				log.Fatal(err)
-				os.Exit(2)
-			}
-			program = append(program, int(num))
-		}
+		BFS(q, vmap)
 	}
-	return program, err
 }
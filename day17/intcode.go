@@ -1,35 +1,15 @@
 package main
 
 import (
-	"bufio"
 	"fmt"
 	"log"
 	"os"
 	"strconv"
-	"strings"
 
+	"github.com/jingqiuELE/advent_code_2019/intcode"
 	flag "github.com/spf13/pflag"
 )
 
-const (
-	ADD           = 1
-	MULTIPLY      = 2
-	STORE         = 3
-	LOAD          = 4
-	JUMP_IF_TRUE  = 5
-	JUMP_IF_FALSE = 6
-	LESS_THAN     = 7
-	EQUALS        = 8
-	RELATIVE_BASE = 9
-	HALT          = 99
-)
-
-const (
-	POSITION  = 0
-	IMMEDIATE = 1
-	RELATIVE  = 2
-)
-
 type Position struct {
 	X int
 	Y int
@@ -83,11 +63,17 @@ func NewVertex(pos Position) *Vertex {
 
 func main() {
 	var dataFile string
+	var traceFile string
+	var debug bool
+	var maxSteps int
 
 	flag.StringVarP(&dataFile, "data file name", "f", "", "")
+	flag.StringVarP(&traceFile, "trace", "t", "", "record the movement run to this trace log")
+	flag.BoolVarP(&debug, "debug", "d", false, "run the movement program under the debugger")
+	flag.IntVarP(&maxSteps, "steps", "s", 0, "stop the movement run after this many instructions")
 	flag.Parse()
 
-	program, err := buildList(dataFile)
+	program, err := intcode.BuildList(dataFile)
 	if err != nil {
 		log.Fatal("Failed to get program from input file!", err)
 	}
@@ -95,7 +81,7 @@ func main() {
 	control := make(chan int, 1)
 	output := make(chan int, 1)
 
-	go runProgram(program, control, output)
+	go intcode.RunProgram(program, control, output)
 
 	var frame [][]int
 	var line []int
@@ -178,46 +164,66 @@ func main() {
 	funcC := "L,8,L,8,R,10,R,4"
 	wantFeed := "n"
 
-	control_1 := make(chan int, 1)
-	output_1 := make(chan int, 1)
+	answers := map[string]string{
+		"Main:":                  mainRoutine,
+		"Function A:":            funcA,
+		"Function B:":            funcB,
+		"Function C:":            funcC,
+		"Continuous video feed?": wantFeed,
+	}
 	program[0] = 2
 
-	go runProgram(program, control_1, output_1)
-	var command string
+	robot := intcode.NewMachine(program)
+	robot.MaxSteps = int64(maxSteps)
+	if traceFile != "" {
+		f, err := os.Create(traceFile)
+		if err != nil {
+			log.Fatal("Failed to create trace file!", err)
+		}
+		defer f.Close()
+		robot.Trace, err = intcode.NewTraceWriter(f, robot)
+		if err != nil {
+			log.Fatal("Failed to start trace!", err)
+		}
+	}
+
+	if debug {
+		// The session stops at the first prompt, which the loop below
+		// answers; the robot then runs on at full speed.
+		d := intcode.NewDebugger(robot, os.Stdin, os.Stderr)
+		if err := d.Session(); err != nil {
+			log.Fatal("Failed to debug the robot!", err)
+		}
+	}
+
+	session := intcode.NewASCII(robot)
 	for {
-		c := <-output_1
-		if c < 255 {
-			fmt.Printf("%c", c)
-		} else {
-			fmt.Printf("result: %v\n", c)
+		e, err := session.Next()
+		if err != nil {
+			log.Fatal("Failed to run the robot!", err)
 		}
-		if c == '\n' {
-			//We got a command
-			switch command {
-			case "Main:":
-				sendControl(control_1, mainRoutine)
-			case "Function A:":
-				sendControl(control_1, funcA)
-			case "Function B:":
-				sendControl(control_1, funcB)
-			case "Function C:":
-				sendControl(control_1, funcC)
-			case "Continuous video feed?":
-				sendControl(control_1, wantFeed)
+		switch e.Kind {
+		case intcode.LineEvent:
+			fmt.Println(e.Text)
+		case intcode.ValueEvent:
+			fmt.Printf("result: %v\n", e.Value)
+		case intcode.PromptEvent:
+			answer, ok := answers[e.Text]
+			if !ok {
+				log.Fatal("Failed to answer prompt!", e.Text)
 			}
-			command = ""
-		} else {
-			command = command + string(c)
+			fmt.Println("sending:", answer)
+			session.Send(answer)
+		}
+		if e.Kind == intcode.HaltEvent {
+			break
 		}
 	}
-}
-
-func sendControl(control chan int, data string) {
-	fmt.Println("sending:", data)
-	for _, c := range data {
-		control <- int(c)
+	if robot.Trace != nil {
+		if err := robot.Trace.Flush(); err != nil {
+			log.Fatal("Failed to write trace!", err)
+		}
 	}
-	control <- '\n'
 }
 
 func stringSteps(steps []int) string {
@@ -327,153 +333,3 @@ func dumpVideo(frame [][]int) {
 		fmt.Println("")
 	}
 }
-
-func runProgram(program []int, input chan int, output chan int) {
-	p := make([]int, len(program)+1024*8)
-	copy(p, program)
-	fmt.Println("p[0]=", p[0])
-
-	relative_base := 0
-	i := 0
-	for i < len(p) {
-		opcode, pmode := parseCode(p[i])
-		switch opcode {
-		case ADD:
-			param0 := loadParam(p[i+1], pmode[0], relative_base, p)
-			param1 := loadParam(p[i+2], pmode[1], relative_base, p)
-			rpos := loadPos(p[i+3], pmode[2], relative_base)
-			p[rpos] = param0 + param1
-			i += 4
-		case MULTIPLY:
-			param0 := loadParam(p[i+1], pmode[0], relative_base, p)
-			param1 := loadParam(p[i+2], pmode[1], relative_base, p)
-			rpos := loadPos(p[i+3], pmode[2], relative_base)
-			p[rpos] = param0 * param1
-			i += 4
-		case STORE:
-			rpos := loadPos(p[i+1], pmode[0], relative_base)
-			p[rpos] = <-input
-			i += 2
-		case LOAD:
-			param0 := loadParam(p[i+1], pmode[0], relative_base, p)
-			output <- param0
-			i += 2
-		case JUMP_IF_TRUE:
-			param0 := loadParam(p[i+1], pmode[0], relative_base, p)
-			param1 := loadParam(p[i+2], pmode[1], relative_base, p)
-			if param0 != int(0) {
-				i = int(param1)
-			} else {
-				i += 3
-			}
-		case JUMP_IF_FALSE:
-			param0 := loadParam(p[i+1], pmode[0], relative_base, p)
-			param1 := loadParam(p[i+2], pmode[1], relative_base, p)
-			if param0 == int(0) {
-				i = int(param1)
-			} else {
-				i += 3
-			}
-		case LESS_THAN:
-			param0 := loadParam(p[i+1], pmode[0], relative_base, p)
-			param1 := loadParam(p[i+2], pmode[1], relative_base, p)
-			rpos := loadPos(p[i+3], pmode[2], relative_base)
-			if param0 < param1 {
-				p[rpos] = 1
-			} else {
-				p[rpos] = 0
-			}
-			i += 4
-		case EQUALS:
-			param0 := loadParam(p[i+1], pmode[0], relative_base, p)
-			param1 := loadParam(p[i+2], pmode[1], relative_base, p)
-			rpos := loadPos(p[i+3], pmode[2], relative_base)
-			if param0 == param1 {
-				p[rpos] = 1
-			} else {
-				p[rpos] = 0
-			}
-			i += 4
-		case RELATIVE_BASE:
-			param0 := loadParam(p[i+1], pmode[0], relative_base, p)
-			relative_base += param0
-			i += 2
-		case HALT:
-			fmt.Println("HALT")
-			close(output)
-			return
-		default:
-			log.Fatalf("unrecognized opcode=%v at %v\n", opcode, i)
-		}
-	}
-}
-
-func parseCode(code int) (opcode int, pmode []int) {
-	var ps int
-
-	opcode = code % 100
-
-	pmode = make([]int, 3)
-
-	ps = (code - opcode) / 100
-	for i := 0; ps > 0; i++ {
-		digit := ps % 10
-		pmode[i] = digit
-		ps = (ps - digit) / 10
-	}
-	return opcode, pmode
-}
-
-func loadParam(data int, mode int, base int, p []int) int {
-	switch mode {
-	case IMMEDIATE:
-		return data
-	case POSITION:
-		return p[data]
-	case RELATIVE:
-		return p[base+data]
-	}
-	log.Fatal("Shouldn't get here")
-	return -1
-}
-
-func loadPos(pos int, mode int, base int) int {
-	switch mode {
-	case POSITION:
-		return pos
-	case RELATIVE:
-		return base + pos
-	}
-	log.Fatal("Shouldn't get here")
-	return -1
-}
-
-func buildList(dataFile string) ([]int, error) {
-	var program []int
-
-	f, err := os.Open(dataFile)
-	if err != nil {
-		return nil, err
-	}
-
-	defer f.Close()
-
-	buff := bufio.NewReader(f)
-	for {
-		line, err := buff.ReadString('\n')
-		if err != nil {
-			break
-		}
-		line = strings.TrimSuffix(line, "\n")
-		lists := strings.Split(line, ",")
-		for _, l := range lists {
-			num, err := strconv.ParseInt(l, 10, 64)
-			if err != nil {
-				log.Fatal(err)
-				os.Exit(2)
-			}
-			program = append(program, int(num))
-		}
-	}
-	return program, err
-}
@@ -1,94 +1,77 @@
 package main
 
 import (
-	"bufio"
 	"fmt"
 	"log"
-	"os"
-	"strconv"
-	"strings"
 
+	"github.com/jingqiuELE/advent_code_2019/intcode"
 	flag "github.com/spf13/pflag"
 )
 
 func main() {
 	var dataFile string
+	var want int
+	var cell int
+	var all bool
 
 	flag.StringVarP(&dataFile, "data file name", "f", "", "")
+	flag.IntVarP(&want, "target", "t", 19690720, "value the program must leave in the result cell")
+	flag.IntVarP(&cell, "cell", "c", 0, "address of the result cell")
+	flag.BoolVarP(&all, "all", "a", false, "print every noun and verb producing the target")
 	flag.Parse()
 
-	program, err := buildList(dataFile)
+	program, err := intcode.BuildList(dataFile)
 	if err != nil {
 		log.Fatal("Failed to get program from input file!", err)
 	}
 
-	var noun int64
-	var verb int64
-	for noun = 0; noun <= 99; noun++ {
-		for verb = 0; verb <= 99; verb++ {
-			result := runProgram(program, noun, verb)
-			if result[0] == 19690720 {
-				fmt.Printf("Found! noun=%v, verb=%v, result=%v\n", noun, verb, 100*noun+verb)
-				return
-			}
-		}
+	solver := intcode.NewSolver(program,
+		intcode.Unknown{Addr: 1, Min: 0, Max: 99},
+		intcode.Unknown{Addr: 2, Min: 0, Max: 99},
+	)
+	solver.Dialect = intcode.Day2
+	if formula, err := solver.Formula(cell); err == nil {
+		fmt.Printf("result[%v] = %v\n", cell, formula)
 	}
-}
 
-func runProgram(program []int64, noun int64, verb int64) []int64 {
-	var p []int64
-	p = make([]int64, len(program))
-	copy(p, program)
-	p[1] = noun
-	p[2] = verb
-	i := 0
-	for p[i] != 99 && i < len(p) {
-		if p[i] == 1 {
-			pos1 := p[i+1]
-			pos2 := p[i+2]
-			rpos := p[i+3]
-			p[rpos] = p[pos1] + p[pos2]
-			i += 4
-		} else if p[i] == 2 {
-			pos1 := p[i+1]
-			pos2 := p[i+2]
-			rpos := p[i+3]
-			p[rpos] = p[pos1] * p[pos2]
-			i += 4
-		} else {
-			fmt.Println("Unrecognized opcode!")
-			i++
-		}
-	}
-	return p
-}
-
-func buildList(dataFile string) ([]int64, error) {
-	var program []int64
-
-	f, err := os.Open(dataFile)
+	solutions, err := solver.Solve(cell, want)
 	if err != nil {
-		return nil, err
+		log.Fatal("Failed to solve!", err)
 	}
-
-	defer f.Close()
-
-	buff := bufio.NewReader(f)
-	for {
-		line, err := buff.ReadString('\n')
+	if len(solutions) == 0 {
+		fmt.Printf("No noun and verb give %v in %v runs\n", want, solver.Runs)
+		return
+	}
+	for _, s := range solutions {
+		noun, verb := s[0], s[1]
+		result, err := runProgram(program, noun, verb, cell)
 		if err != nil {
-			break
+			log.Fatal("Failed to check solution!", err)
 		}
-		line = strings.TrimSuffix(line, "\n")
-		lists := strings.Split(line, ",")
-		for _, l := range lists {
-			num, err := strconv.ParseInt(l, 10, 64)
-			if err != nil {
-				log.Fatal(err)
-				os.Exit(2)
-			}
-			program = append(program, num)
+		if result != want {
+			log.Fatal("Solution does not check out!", result)
 		}
+		fmt.Printf("Found! noun=%v, verb=%v, result=%v\n", noun, verb, 100*noun+verb)
+		if !all {
+			break
+		}
+	}
+	fmt.Printf("%v solutions in %v runs\n", len(solutions), solver.Runs)
+}
+
+// runProgram runs program with noun and verb and returns the value it leaves
+// in cell.
+func runProgram(program []int, noun int, verb int, cell int) (int, error) {
+	m := intcode.NewMachine(program)
+	m.Dialect = intcode.Day2
+	if err := m.Write(1, noun); err != nil {
+		return 0, err
+	}
+	if err := m.Write(2, verb); err != nil {
+		return 0, err
+	}
+	if err := m.Run(nil, nil); err != nil {
+		return 0, err
 	}
-	return program, err
+	return m.Memory.Load(cell)
 }
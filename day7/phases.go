@@ -0,0 +1,197 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+
+	"github.com/jingqiuELE/advent_code_2019/intcode"
+)
+
+// parseCandidates parses a comma-separated list of phase settings. Every
+// amplifier needs a different setting, so a repeated value is an error.
+func parseCandidates(list string) ([]int, error) {
+	candidates, err := intcode.ParseProgram(list)
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[int]bool)
+	for _, c := range candidates {
+		if seen[c] {
+			return nil, fmt.Errorf("duplicate phase setting %d", c)
+		}
+		seen[c] = true
+	}
+	return candidates, nil
+}
+
+// permutations streams every ordering of k distinct values taken from
+// candidates. Each k-subset is permuted in place with Heap's algorithm, so
+// only one setting is held in memory at a time. Closing done stops it.
+func permutations(candidates []int, k int, done <-chan struct{}) <-chan []int {
+	out := make(chan []int)
+
+	go func() {
+		defer close(out)
+		if k < 1 || k > len(candidates) {
+			return
+		}
+
+		emit := func(a []int) bool {
+			setting := append([]int(nil), a...)
+			select {
+			case out <- setting:
+				return true
+			case <-done:
+				return false
+			}
+		}
+
+		chosen := make([]int, 0, k)
+		var subsets func(start int) bool
+		subsets = func(start int) bool {
+			if len(chosen) == k {
+				return heap(append([]int(nil), chosen...), emit)
+			}
+			for i := start; i <= len(candidates)-(k-len(chosen)); i++ {
+				chosen = append(chosen, candidates[i])
+				if !subsets(i + 1) {
+					return false
+				}
+				chosen = chosen[:len(chosen)-1]
+			}
+			return true
+		}
+		subsets(0)
+	}()
+	return out
+}
+
+// heap calls emit with every permutation of a, stopping early when emit
+// returns false.
+func heap(a []int, emit func([]int) bool) bool {
+	c := make([]int, len(a))
+	if !emit(a) {
+		return false
+	}
+	for i := 1; i < len(a); {
+		if c[i] < i {
+			if i%2 == 0 {
+				a[0], a[i] = a[i], a[0]
+			} else {
+				a[c[i]], a[i] = a[i], a[c[i]]
+			}
+			if !emit(a) {
+				return false
+			}
+			c[i]++
+			i = 1
+		} else {
+			c[i] = 0
+			i++
+		}
+	}
+	return true
+}
+
+// Outcome is the signal of one phase setting, or the reason it failed.
+type Outcome struct {
+	Phases []int
+	Signal int
+	Err    error
+}
+
+// Report is the result of a phase search.
+type Report struct {
+	Best     Outcome
+	Outcomes []Outcome
+	Failed   int
+}
+
+// searchPhases runs circuit with every setting of its amplifiers drawn from
+// candidates, on workers goroutines at once. Outcomes are sorted by signal,
+// highest first; equal signals are ordered by setting so the report does not
+// depend on scheduling.
+func searchPhases(circuit *Topology, program []int, candidates []int, workers int) Report {
+	if workers < 1 {
+		workers = 1
+	}
+	done := make(chan struct{})
+	defer close(done)
+	settings := permutations(candidates, len(circuit.Nodes), done)
+
+	outcomes := make(chan Outcome)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for phases := range settings {
+				signal, err := circuit.Run(program, phases, 0)
+				outcomes <- Outcome{Phases: phases, Signal: signal, Err: err}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(outcomes)
+	}()
+
+	var r Report
+	for o := range outcomes {
+		if o.Err != nil {
+			r.Failed++
+		}
+		r.Outcomes = append(r.Outcomes, o)
+	}
+	sort.Slice(r.Outcomes, func(i, j int) bool {
+		a, b := r.Outcomes[i], r.Outcomes[j]
+		if (a.Err == nil) != (b.Err == nil) {
+			return a.Err == nil
+		}
+		if a.Signal != b.Signal {
+			return a.Signal > b.Signal
+		}
+		return less(a.Phases, b.Phases)
+	})
+	if len(r.Outcomes) > r.Failed {
+		r.Best = r.Outcomes[0]
+	}
+	return r
+}
+
+func less(a []int, b []int) bool {
+	for i := range a {
+		if a[i] != b[i] {
+			return a[i] < b[i]
+		}
+	}
+	return false
+}
+
+// Print writes every outcome followed by a summary of the distribution.
+func (r Report) Print(w io.Writer) {
+	for _, o := range r.Outcomes {
+		if o.Err != nil {
+			fmt.Fprintf(w, "%v: %v\n", o.Phases, o.Err)
+		} else {
+			fmt.Fprintf(w, "%v: %v\n", o.Phases, o.Signal)
+		}
+	}
+
+	ok := r.Outcomes[:len(r.Outcomes)-r.Failed]
+	fmt.Fprintf(w, "settings=%d, failed=%d\n", len(r.Outcomes), r.Failed)
+	if len(ok) == 0 {
+		return
+	}
+	sum := 0
+	distinct := make(map[int]bool)
+	for _, o := range ok {
+		sum += o.Signal
+		distinct[o.Signal] = true
+	}
+	fmt.Fprintf(w, "min=%d, median=%d, mean=%.1f, max=%d, distinct signals=%d\n",
+		ok[len(ok)-1].Signal, ok[len(ok)/2].Signal, float64(sum)/float64(len(ok)), ok[0].Signal, len(distinct))
+	fmt.Fprintf(w, "best phases=%v\n", r.Best.Phases)
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestPermutations(t *testing.T) {
+	tests := []struct {
+		n, k int
+		want int
+	}{
+		{5, 5, 120},
+		{5, 3, 60},
+		{4, 1, 4},
+		{3, 0, 0},
+		{3, 4, 0},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("%d of %d", test.k, test.n), func(t *testing.T) {
+			candidates := make([]int, test.n)
+			for i := range candidates {
+				candidates[i] = i + 5
+			}
+
+			seen := make(map[string]bool)
+			for setting := range permutations(candidates, test.k, nil) {
+				if len(setting) != test.k {
+					t.Fatalf("setting %v has %d phases, want %d", setting, len(setting), test.k)
+				}
+				used := make(map[int]bool)
+				for _, p := range setting {
+					if used[p] {
+						t.Fatalf("setting %v repeats %d", setting, p)
+					}
+					used[p] = true
+				}
+				key := fmt.Sprint(setting)
+				if seen[key] {
+					t.Fatalf("setting %v produced twice", setting)
+				}
+				seen[key] = true
+			}
+			if len(seen) != test.want {
+				t.Fatalf("got %d settings, want %d", len(seen), test.want)
+			}
+		})
+	}
+}
+
+func TestPermutationsStop(t *testing.T) {
+	done := make(chan struct{})
+	settings := permutations([]int{0, 1, 2, 3, 4}, 5, done)
+	<-settings
+	close(done)
+	for range settings {
+	}
+}
+
+func TestSearchPhases(t *testing.T) {
+	circuit, err := Ring(5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := searchPhases(circuit, feedback, []int{5, 6, 7, 8, 9}, 4)
+	if len(r.Outcomes) != 120 || r.Failed != 0 {
+		t.Fatalf("got %d outcomes with %d failed, want 120 with none failed", len(r.Outcomes), r.Failed)
+	}
+	if want := []int{9, 8, 7, 6, 5}; !reflect.DeepEqual(r.Best.Phases, want) || r.Best.Signal != 139629729 {
+		t.Fatalf("best %v: %d, want %v: 139629729", r.Best.Phases, r.Best.Signal, want)
+	}
+}
+
+func TestParseCandidates(t *testing.T) {
+	got, err := parseCandidates("5,6,7,8,9")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []int{5, 6, 7, 8, 9}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	if _, err := parseCandidates("5,6,5"); err == nil {
+		t.Fatal("duplicate candidates: got no error")
+	}
+	if _, err := parseCandidates("5,x"); err == nil {
+		t.Fatal("bad candidate: got no error")
+	}
+}
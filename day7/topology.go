@@ -0,0 +1,212 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strconv"
+	"strings"
+
+	"github.com/jingqiuELE/advent_code_2019/intcode"
+)
+
+var ErrDeadlock = errors.New("circuit deadlocked")
+
+// Topology wires amplifiers together. It is read from a small config:
+//
+//	# five amplifiers in a feedback loop
+//	node A 5        # name and initial inputs, usually the phase setting
+//	node B 6
+//	A -> B          # outputs of A are inputs of B
+//	E -> A
+//	input A         # the node receiving the first signal
+//	output E        # the node whose last output is the result
+//
+// Any graph works: a chain, a ring or a DAG with fan-out and fan-in. A node
+// with several sources reads their outputs in the order they are produced.
+type Topology struct {
+	Nodes  []Node
+	Input  string
+	Output string
+
+	edges map[string][]string
+}
+
+// Node is an amplifier of a topology.
+type Node struct {
+	Name   string
+	Inputs []int
+}
+
+// Chain returns n amplifiers named A, B, ... connected in series.
+func Chain(n int) (*Topology, error) {
+	if n < 1 {
+		return nil, fmt.Errorf("a circuit needs at least one amplifier, not %d", n)
+	}
+	t := Topology{
+		edges: make(map[string][]string),
+	}
+	for i := 0; i < n; i++ {
+		t.Nodes = append(t.Nodes, Node{Name: string(rune('A' + i))})
+		if i > 0 {
+			t.Connect(t.Nodes[i-1].Name, t.Nodes[i].Name)
+		}
+	}
+	t.Input = t.Nodes[0].Name
+	t.Output = t.Nodes[n-1].Name
+	return &t, nil
+}
+
+// Ring returns a chain whose last amplifier feeds back into the first.
+func Ring(n int) (*Topology, error) {
+	t, err := Chain(n)
+	if err != nil {
+		return nil, err
+	}
+	t.Connect(t.Output, t.Input)
+	return t, nil
+}
+
+// HasInputs reports whether any node of the config sets initial inputs.
+func (t *Topology) HasInputs() bool {
+	for _, n := range t.Nodes {
+		if len(n.Inputs) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Connect sends the outputs of from to to.
+func (t *Topology) Connect(from string, to string) {
+	t.edges[from] = append(t.edges[from], to)
+}
+
+// LoadTopology reads a topology config file.
+func LoadTopology(path string) (*Topology, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseTopology(string(data))
+}
+
+// ParseTopology parses the config format described at Topology.
+func ParseTopology(text string) (*Topology, error) {
+	t := Topology{
+		edges: make(map[string][]string),
+	}
+	names := make(map[string]bool)
+
+	scanner := bufio.NewScanner(strings.NewReader(text))
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := scanner.Text()
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		fields := strings.Fields(line)
+		switch {
+		case len(fields) == 0:
+		case fields[0] == "node" && len(fields) >= 2:
+			n := Node{Name: fields[1]}
+			for _, f := range fields[2:] {
+				v, err := strconv.Atoi(f)
+				if err != nil {
+					return nil, fmt.Errorf("line %d: bad input %q", lineNo, f)
+				}
+				n.Inputs = append(n.Inputs, v)
+			}
+			if names[n.Name] {
+				return nil, fmt.Errorf("line %d: duplicate node %q", lineNo, n.Name)
+			}
+			names[n.Name] = true
+			t.Nodes = append(t.Nodes, n)
+		case fields[0] == "input" && len(fields) == 2:
+			t.Input = fields[1]
+		case fields[0] == "output" && len(fields) == 2:
+			t.Output = fields[1]
+		case len(fields) == 3 && fields[1] == "->":
+			t.Connect(fields[0], fields[2])
+		default:
+			return nil, fmt.Errorf("line %d: cannot parse %q", lineNo, scanner.Text())
+		}
+	}
+
+	for from, tos := range t.edges {
+		for _, to := range tos {
+			if !names[from] || !names[to] {
+				return nil, fmt.Errorf("edge %s -> %s: unknown node", from, to)
+			}
+		}
+	}
+	if !names[t.Input] || !names[t.Output] {
+		return nil, fmt.Errorf("input %q or output %q is not a node", t.Input, t.Output)
+	}
+	return &t, nil
+}
+
+// Run builds a fresh machine running program for every node, feeds it the
+// node's inputs, or phases[i] for the i-th node when phases is given, sends
+// signal to the input node and runs the circuit until every machine halts.
+// It returns the last output of the output node. Machines are scheduled in
+// turn without goroutines, so a circuit where every running machine waits
+// for input that no one will send is reported as ErrDeadlock.
+func (t *Topology) Run(program []int, phases []int, signal int) (int, error) {
+	if phases != nil && len(phases) != len(t.Nodes) {
+		return 0, fmt.Errorf("%d phases for %d amplifiers", len(phases), len(t.Nodes))
+	}
+
+	machines := make(map[string]*intcode.Machine)
+	for i, n := range t.Nodes {
+		m := intcode.NewMachine(program)
+		if phases != nil {
+			m.Feed(phases[i])
+		} else {
+			m.Feed(n.Inputs...)
+		}
+		machines[n.Name] = m
+	}
+	machines[t.Input].Feed(signal)
+
+	result, seen := 0, false
+	for {
+		progress, running := false, false
+		var waiting []string
+		for _, n := range t.Nodes {
+			m := machines[n.Name]
+			if m.Halted {
+				continue
+			}
+			steps := m.Steps
+			out, err := m.Resume(0)
+			if errors.Is(err, intcode.ErrNoInput) {
+				waiting = append(waiting, n.Name)
+			} else if err != nil {
+				return 0, fmt.Errorf("amplifier %s: %w", n.Name, err)
+			}
+			if m.Steps != steps {
+				progress = true
+			}
+			running = running || !m.Halted
+
+			for _, to := range t.edges[n.Name] {
+				machines[to].Feed(out...)
+			}
+			if n.Name == t.Output && len(out) > 0 {
+				result, seen = out[len(out)-1], true
+			}
+		}
+		if !running {
+			break
+		}
+		if !progress {
+			return 0, fmt.Errorf("%w: %v waiting for input", ErrDeadlock, waiting)
+		}
+	}
+
+	if !seen {
+		return 0, fmt.Errorf("amplifier %s produced no signal", t.Output)
+	}
+	return result, nil
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// feedback is the second feedback loop example of day 7, whose best setting
+// 9,8,7,6,5 gives 139629729.
+var feedback = []int{
+	3, 26, 1001, 26, -4, 26, 3, 27, 1002, 27, 2, 27, 1, 27, 26, 27, 4, 27,
+	1001, 28, -1, 28, 1005, 28, 6, 99, 0, 0, 5,
+}
+
+const ring = `
+# the feedback loop of day 7
+node A 9
+node B 8
+node C 7
+node D 6
+node E 5
+A -> B
+B -> C
+C -> D
+D -> E
+E -> A   # the feedback
+input A
+output E
+`
+
+func TestRunRing(t *testing.T) {
+	circuit, err := ParseTopology(ring)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := circuit.Run(feedback, nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != 139629729 {
+		t.Fatalf("config: got %d, want 139629729", got)
+	}
+
+	circuit, err = Ring(5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err = circuit.Run(feedback, []int{9, 8, 7, 6, 5}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != 139629729 {
+		t.Fatalf("Ring: got %d, want 139629729", got)
+	}
+}
+
+func TestRunDeadlock(t *testing.T) {
+	// Each amplifier reads two values before writing anything, so the
+	// signal alone never gets the chain going.
+	program := []int{3, 9, 3, 10, 4, 10, 99, 0, 0, 0, 0}
+	circuit, err := Chain(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := circuit.Run(program, nil, 0); !errors.Is(err, ErrDeadlock) {
+		t.Fatalf("got %v, want %v", err, ErrDeadlock)
+	}
+}
+
+func TestParseTopologyErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{"bad input", "node A x\ninput A\noutput A", `line 1: bad input "x"`},
+		{"duplicate node", "node A\nnode A\ninput A\noutput A", `line 2: duplicate node "A"`},
+		{"unknown node", "node A\nA -> B\ninput A\noutput A", "edge A -> B: unknown node"},
+		{"no input", "node A\noutput A", `input "" or output "A" is not a node`},
+		{"unknown output", "node A\ninput A\noutput B", `input "A" or output "B" is not a node`},
+		{"garbage", "node A\nA to B\ninput A\noutput A", `line 2: cannot parse "A to B"`},
+		{"nameless node", "node\ninput A\noutput A", `line 1: cannot parse "node"`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := ParseTopology(test.text)
+			if err == nil {
+				t.Fatalf("got no error, want %q", test.want)
+			}
+			if !strings.Contains(err.Error(), test.want) {
+				t.Fatalf("got %q, want %q", err, test.want)
+			}
+		})
+	}
+}
+
+func TestChainSize(t *testing.T) {
+	if _, err := Chain(0); err == nil {
+		t.Fatal("Chain(0): got no error")
+	}
+	if _, err := Ring(-1); err == nil {
+		t.Fatal("Ring(-1): got no error")
+	}
+}
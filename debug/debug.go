@@ -0,0 +1,50 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/jingqiuELE/advent_code_2019/intcode"
+	flag "github.com/spf13/pflag"
+)
+
+func main() {
+	var dataFile string
+	var inputs string
+
+	flag.StringVarP(&dataFile, "data file name", "f", "", "")
+	flag.StringVarP(&inputs, "inputs", "i", "", "comma-separated values fed to the program")
+	flag.Parse()
+
+	program, err := intcode.BuildList(dataFile)
+	if err != nil {
+		log.Fatal("Failed to get program from input file!", err)
+	}
+
+	values, err := intcode.ParseProgram(inputs)
+	if err != nil {
+		log.Fatal("Failed to parse inputs!", err)
+	}
+
+	input := make(chan int, len(values))
+	for _, v := range values {
+		input <- v
+	}
+	close(input)
+	output := make(chan int)
+	done := make(chan bool)
+	go func() {
+		for v := range output {
+			fmt.Printf("output: %v\n", v)
+		}
+		done <- true
+	}()
+
+	m := intcode.NewMachine(program)
+	err = intcode.NewDebugger(m, os.Stdin, os.Stdout).Run(input, output)
+	<-done
+	if err != nil && err != intcode.ErrQuit {
+		os.Exit(1)
+	}
+}
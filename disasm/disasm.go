@@ -0,0 +1,74 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/jingqiuELE/advent_code_2019/intcode"
+	flag "github.com/spf13/pflag"
+)
+
+func main() {
+	var dataFile string
+	var traceFile string
+
+	flag.StringVarP(&dataFile, "data file name", "f", "", "")
+	flag.StringVarP(&traceFile, "trace file name", "t", "", "trace log or list of executed addresses")
+	flag.Parse()
+
+	program, err := intcode.BuildList(dataFile)
+	if err != nil {
+		log.Fatal("Failed to get program from input file!", err)
+	}
+
+	var executed map[int]bool
+	if traceFile != "" {
+		executed, err = loadTrace(traceFile)
+		if err != nil {
+			log.Fatal("Failed to load trace file!", err)
+		}
+	}
+
+	for _, line := range intcode.Disassemble(program, executed) {
+		if line.Inst == nil && executed != nil {
+			fmt.Printf("%-40s ; never executed\n", line)
+		} else {
+			fmt.Println(line)
+		}
+	}
+}
+
+// loadTrace reads the addresses of executed instructions, either from a trace
+// log or from a list in the same comma-separated format as a program.
+func loadTrace(traceFile string) (map[int]bool, error) {
+	f, err := os.Open(traceFile)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	_, steps, err := intcode.ReadTrace(f)
+	if err == nil {
+		executed := make(map[int]bool)
+		for _, s := range steps {
+			executed[s.IP] = true
+		}
+		return executed, nil
+	}
+	if !errors.Is(err, intcode.ErrNotTrace) {
+		return nil, err
+	}
+
+	addrs, err := intcode.BuildList(traceFile)
+	if err != nil {
+		return nil, err
+	}
+
+	executed := make(map[int]bool)
+	for _, addr := range addrs {
+		executed[addr] = true
+	}
+	return executed, nil
+}
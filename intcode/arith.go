@@ -0,0 +1,29 @@
+package intcode
+
+import "fmt"
+
+const (
+	maxInt = int(^uint(0) >> 1)
+	minInt = -maxInt - 1
+)
+
+// addChecked returns a+b, or ErrOverflow if the sum does not fit in an int.
+func addChecked(a int, b int) (int, error) {
+	if (b > 0 && a > maxInt-b) || (b < 0 && a < minInt-b) {
+		return 0, fmt.Errorf("%w: %v + %v", ErrOverflow, a, b)
+	}
+	return a + b, nil
+}
+
+// mulChecked returns a*b, or ErrOverflow if the product does not fit in an
+// int.
+func mulChecked(a int, b int) (int, error) {
+	if a == 0 || b == 0 {
+		return 0, nil
+	}
+	c := a * b
+	if c/b != a || (a == -1 && b == minInt) || (b == -1 && a == minInt) {
+		return 0, fmt.Errorf("%w: %v * %v", ErrOverflow, a, b)
+	}
+	return c, nil
+}
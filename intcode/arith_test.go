@@ -0,0 +1,118 @@
+package intcode
+
+import (
+	"errors"
+	"io/ioutil"
+	"testing"
+)
+
+func TestAddChecked(t *testing.T) {
+	tests := []struct {
+		a, b     int
+		overflow bool
+	}{
+		{1, 2, false},
+		{maxInt, 0, false},
+		{maxInt, 1, true},
+		{maxInt - 1, 1, false},
+		{minInt, -1, true},
+		{minInt, 1, false},
+		{minInt, maxInt, false},
+		{-1, minInt + 1, false},
+	}
+	for _, tt := range tests {
+		got, err := addChecked(tt.a, tt.b)
+		if overflow := errors.Is(err, ErrOverflow); overflow != tt.overflow {
+			t.Errorf("addChecked(%d, %d): error %v, want overflow %v", tt.a, tt.b, err, tt.overflow)
+		} else if !overflow && got != tt.a+tt.b {
+			t.Errorf("addChecked(%d, %d) = %d, want %d", tt.a, tt.b, got, tt.a+tt.b)
+		}
+	}
+}
+
+func TestMulChecked(t *testing.T) {
+	tests := []struct {
+		a, b     int
+		overflow bool
+	}{
+		{3, 4, false},
+		{0, minInt, false},
+		{-1, maxInt, false},
+		{-1, minInt, true},
+		{minInt, -1, true},
+		{1 << 32, 1 << 31, true},
+		{1 << 31, 1 << 31, false},
+		{maxInt/2 + 1, 2, true},
+		{minInt / 2, 2, false},
+		{-(1 << 32), 1 << 31, false},
+		{-(1 << 32), 1 << 32, true},
+	}
+	for _, tt := range tests {
+		got, err := mulChecked(tt.a, tt.b)
+		if overflow := errors.Is(err, ErrOverflow); overflow != tt.overflow {
+			t.Errorf("mulChecked(%d, %d): error %v, want overflow %v", tt.a, tt.b, err, tt.overflow)
+		} else if !overflow && got != tt.a*tt.b {
+			t.Errorf("mulChecked(%d, %d) = %d, want %d", tt.a, tt.b, got, tt.a*tt.b)
+		}
+	}
+}
+
+func TestCheckedMachine(t *testing.T) {
+	tests := []struct {
+		name    string
+		program []int
+		want    int
+	}{
+		// Doubles [9] until it no longer fits.
+		{"mul", []int{1002, 9, 2, 9, 1105, 1, 0, 99, 99, 1}, 1 << 62},
+		// Adds [9] to itself, the same with ADD.
+		{"add", []int{1, 9, 9, 9, 1105, 1, 0, 99, 99, 1}, 1 << 62},
+		// Subtracts 2^62 from [9] until it goes below minInt.
+		{"sub", []int{1001, 9, -(1 << 62), 9, 1105, 1, 0, 99, 99, 0}, minInt},
+	}
+	for _, tt := range tests {
+		m := NewMachine(tt.program)
+		m.Checked = true
+		err := m.Run(nil, nil)
+		var e *MachineError
+		if !errors.Is(err, ErrOverflow) || !errors.As(err, &e) || e.IP != 0 {
+			t.Errorf("%s: got %v, want overflow at ip 0", tt.name, err)
+			continue
+		}
+		if got := m.Read(9); got != tt.want {
+			t.Errorf("%s: [9] = %d when faulting, want %d", tt.name, got, tt.want)
+		}
+
+		wrap := NewMachine(tt.program)
+		wrap.MaxSteps = 1000
+		if err := wrap.Run(nil, nil); !errors.Is(err, ErrStepLimit) {
+			t.Errorf("%s: unchecked machine got %v, want it to wrap around until the step limit", tt.name, err)
+		}
+	}
+}
+
+func TestCloneKeepsSettings(t *testing.T) {
+	m := NewMachine([]int{1, 9, 9, 9, 1105, 1, 0, 99, 99, 1})
+	m.Checked = true
+	m.MaxSteps = 500
+	m.Profile = NewProfile()
+	tw, err := NewTraceWriter(ioutil.Discard, m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.Trace = tw
+	if err := m.Step(); err != nil {
+		t.Fatal(err)
+	}
+
+	c := m.Clone()
+	if !c.Checked || c.MaxSteps != m.MaxSteps || c.Steps != m.Steps {
+		t.Fatalf("clone lost settings: checked=%v max=%d steps=%d", c.Checked, c.MaxSteps, c.Steps)
+	}
+	if c.Profile != nil || c.Trace != nil {
+		t.Fatal("clone records into the profile or trace of its parent")
+	}
+	if err := c.Run(nil, nil); !errors.Is(err, ErrOverflow) {
+		t.Fatalf("clone got %v, want overflow", err)
+	}
+}
@@ -0,0 +1,145 @@
+package intcode
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// EventKind tells what an ASCII program did.
+type EventKind int
+
+const (
+	// LineEvent is a complete line of text, without its newline.
+	LineEvent EventKind = iota
+	// PromptEvent means the program waits for a line of input.
+	PromptEvent
+	// ValueEvent is an output outside the ASCII range, usually the answer.
+	ValueEvent
+	// HaltEvent means the program has halted.
+	HaltEvent
+)
+
+// Event is one step of a conversation with an ASCII program.
+type Event struct {
+	Kind  EventKind
+	Text  string
+	Value int
+	// Partial is set on a prompt whose Text is a line the program has not
+	// finished, such as "Command? ". Otherwise Text is the last complete
+	// line, already reported as a LineEvent.
+	Partial bool
+}
+
+// ASCII talks to a program that reads and writes lines of text. It runs the
+// machine synchronously with Resume, so the machine needs no input source or
+// output sink.
+type ASCII struct {
+	Machine *Machine
+	// Echo makes Run write the answers it sends, as when they come from a
+	// script rather than a terminal.
+	Echo bool
+
+	line   strings.Builder
+	last   string
+	halted bool
+}
+
+// NewASCII returns an ASCII conversation with m.
+func NewASCII(m *Machine) *ASCII {
+	return &ASCII{Machine: m}
+}
+
+// Send queues line as input, terminated by a newline.
+func (a *ASCII) Send(line string) {
+	for _, c := range line {
+		a.Machine.Feed(int(c))
+	}
+	a.Machine.Feed('\n')
+}
+
+// Next runs the program until it completes a line, writes a non-ASCII value,
+// waits for input or halts. After the HaltEvent it returns ErrHalted.
+func (a *ASCII) Next() (Event, error) {
+	m := a.Machine
+
+	for {
+		if m.Halted {
+			if a.line.Len() > 0 {
+				return a.flush(), nil
+			}
+			if a.halted {
+				return Event{}, ErrHalted
+			}
+			a.halted = true
+			return Event{Kind: HaltEvent}, nil
+		}
+
+		out, err := m.Resume(1)
+		if errors.Is(err, ErrNoInput) {
+			if a.line.Len() > 0 {
+				e := a.flush()
+				e.Kind, e.Partial = PromptEvent, true
+				return e, nil
+			}
+			return Event{Kind: PromptEvent, Text: a.last}, nil
+		}
+		if err != nil {
+			return Event{}, err
+		}
+		for _, v := range out {
+			switch {
+			case v == '\n':
+				return a.flush(), nil
+			case v < 0 || v > 255:
+				return Event{Kind: ValueEvent, Value: v}, nil
+			default:
+				a.line.WriteByte(byte(v))
+			}
+		}
+	}
+}
+
+func (a *ASCII) flush() Event {
+	a.last = a.line.String()
+	a.line.Reset()
+	return Event{Kind: LineEvent, Text: a.last}
+}
+
+// Run holds the whole conversation: lines and values are written to out and
+// every prompt is answered with the next line read from in, a terminal or a
+// script. It returns ErrInputClosed if in ends while the program waits.
+func (a *ASCII) Run(in io.Reader, out io.Writer) error {
+	answers := bufio.NewScanner(in)
+
+	for {
+		e, err := a.Next()
+		if err != nil {
+			return err
+		}
+		switch e.Kind {
+		case LineEvent:
+			fmt.Fprintln(out, e.Text)
+		case ValueEvent:
+			fmt.Fprintln(out, e.Value)
+		case PromptEvent:
+			if e.Partial {
+				fmt.Fprint(out, e.Text)
+			}
+			if !answers.Scan() {
+				if err := answers.Err(); err != nil {
+					return err
+				}
+				return ErrInputClosed
+			}
+			if a.Echo {
+				fmt.Fprintln(out, answers.Text())
+			}
+			a.Send(answers.Text())
+		case HaltEvent:
+			return nil
+		}
+	}
+}
@@ -0,0 +1,500 @@
+package intcode
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Assembler source format, one statement per line:
+//
+//	; comment
+//	label:  ADD  [a], #1, rb+2     ; position, immediate and relative operands
+//	        db   1, 2, "text", end ; data words, strings become ASCII codes
+//	        push [a]               ; built-in stack macros on relative base
+//	        pop  [a]
+//	        call func
+//	        ret
+//	macro inc x                    ; user macros, parameters are substituted
+//	        ADD x, #1, x           ; by name
+//	endm
+//
+// Mnemonics are the disassembler names (ADD, MUL, IN, OUT, JT, JF, LT, EQ,
+// ARB, HLT) or the opcode constant names (MULTIPLY, STORE, ... HALT). An
+// operand without a mode prefix is in position mode. Expressions are sums of
+// numbers, labels and $, the address of the current statement.
+//
+// Labels defined inside a macro body are local to each expansion, so a macro
+// with a loop can be used more than once.
+//
+// The stack macros treat the relative base as a stack pointer to the next
+// free cell. call pushes the return address and jumps, ret pops it and jumps
+// back. Operands of pop are evaluated after the stack pointer is moved.
+
+var opcodeNames = map[string]int{
+	"ADD":           ADD,
+	"MUL":           MULTIPLY,
+	"MULTIPLY":      MULTIPLY,
+	"IN":            STORE,
+	"STORE":         STORE,
+	"OUT":           LOAD,
+	"LOAD":          LOAD,
+	"JT":            JUMP_IF_TRUE,
+	"JUMP_IF_TRUE":  JUMP_IF_TRUE,
+	"JF":            JUMP_IF_FALSE,
+	"JUMP_IF_FALSE": JUMP_IF_FALSE,
+	"LT":            LESS_THAN,
+	"LESS_THAN":     LESS_THAN,
+	"EQ":            EQUALS,
+	"EQUALS":        EQUALS,
+	"ARB":           RELATIVE_BASE,
+	"RELATIVE_BASE": RELATIVE_BASE,
+	"HLT":           HALT,
+	"HALT":          HALT,
+}
+
+type statement struct {
+	// pos is the source line, followed for a macro expansion by the line
+	// of the macro body.
+	pos      string
+	labels   []string
+	op       string
+	operands []string
+	addr     int
+}
+
+type macro struct {
+	name   string
+	params []string
+	body   []string
+	// pos returns the position of line n of the body.
+	pos func(n int) string
+}
+
+type assembler struct {
+	macros     map[string]*macro
+	statements []statement
+	labels     map[string]int
+	// expansions counts macro expansions, to name their local labels.
+	expansions int
+}
+
+// Assemble translates assembler source into a program image.
+func Assemble(src string) ([]int, error) {
+	a := assembler{
+		macros: make(map[string]*macro),
+		labels: make(map[string]int),
+	}
+	lines := func(n int) string {
+		return fmt.Sprintf("line %d", n+1)
+	}
+	if err := a.parse(strings.Split(src, "\n"), lines, nil); err != nil {
+		return nil, err
+	}
+
+	addr := 0
+	for i := range a.statements {
+		s := &a.statements[i]
+		s.addr = addr
+		for _, l := range s.labels {
+			if _, ok := a.labels[l]; ok {
+				return nil, fmt.Errorf("%s: duplicate label %q", s.pos, l)
+			}
+			a.labels[l] = addr
+		}
+		size, err := a.size(s)
+		if err != nil {
+			return nil, err
+		}
+		addr += size
+	}
+
+	var program []int
+	for i := range a.statements {
+		words, err := a.emit(&a.statements[i])
+		if err != nil {
+			return nil, err
+		}
+		program = append(program, words...)
+	}
+	return program, nil
+}
+
+// parse appends the statements of lines, expanding macros. where returns
+// the position of lines[n] for messages; args substitutes macro parameters
+// and the labels local to a macro.
+func (a *assembler) parse(lines []string, where func(n int) string, args map[string]string) error {
+	var current *macro
+
+	for n, text := range lines {
+		pos := where(n)
+		text = strings.TrimSpace(stripComment(text))
+		if args != nil {
+			text = substitute(text, args)
+		}
+
+		fields := strings.Fields(text)
+		if current != nil {
+			if len(fields) > 0 && strings.EqualFold(fields[0], "endm") {
+				current = nil
+			} else {
+				current.body = append(current.body, text)
+			}
+			continue
+		}
+		if len(fields) > 0 && strings.EqualFold(fields[0], "macro") {
+			if len(fields) < 2 {
+				return fmt.Errorf("%s: macro without a name", pos)
+			}
+			start := n + 1
+			current = &macro{
+				name:   strings.ToLower(fields[1]),
+				params: splitOperands(strings.Join(fields[2:], " ")),
+				pos: func(k int) string {
+					return where(start + k)
+				},
+			}
+			a.macros[strings.ToLower(fields[1])] = current
+			continue
+		}
+
+		labels, text := splitLabels(text)
+
+		op := text
+		rest := ""
+		if i := strings.IndexAny(text, " \t"); i >= 0 {
+			op = text[:i]
+			rest = strings.TrimSpace(text[i:])
+		}
+		s := statement{
+			pos:      pos,
+			labels:   labels,
+			op:       strings.ToUpper(op),
+			operands: splitOperands(rest),
+		}
+		if err := a.expand(s); err != nil {
+			return err
+		}
+	}
+	if current != nil {
+		return fmt.Errorf("%s: macro without endm", where(len(lines)-1))
+	}
+	return nil
+}
+
+// expand appends s, replacing macro invocations by their bodies.
+func (a *assembler) expand(s statement) error {
+	add := func(op string, operands ...string) {
+		a.statements = append(a.statements, statement{
+			pos:      s.pos,
+			labels:   s.labels,
+			op:       op,
+			operands: operands,
+		})
+		s.labels = nil
+	}
+
+	switch s.op {
+	case "":
+		if len(s.labels) > 0 {
+			add("DB")
+		}
+		return nil
+	case "PUSH":
+		if len(s.operands) != 1 {
+			return fmt.Errorf("%s: push takes one operand", s.pos)
+		}
+		add("ADD", s.operands[0], "#0", "rb+0")
+		add("ARB", "#1")
+		return nil
+	case "POP":
+		if len(s.operands) > 1 {
+			return fmt.Errorf("%s: pop takes at most one operand", s.pos)
+		}
+		add("ARB", "#-1")
+		if len(s.operands) == 1 {
+			add("ADD", "rb+0", "#0", s.operands[0])
+		}
+		return nil
+	case "CALL":
+		if len(s.operands) != 1 {
+			return fmt.Errorf("%s: call takes one operand", s.pos)
+		}
+		target := s.operands[0]
+		if modeOf(target) == POSITION && !strings.HasPrefix(target, "[") {
+			target = "#" + target
+		}
+		add("ADD", "#$+9", "#0", "rb+0")
+		add("ARB", "#1")
+		add("JT", "#1", target)
+		return nil
+	case "RET":
+		add("ARB", "#-1")
+		add("JT", "#1", "rb+0")
+		return nil
+	}
+
+	m := a.macros[strings.ToLower(s.op)]
+	if m == nil {
+		a.statements = append(a.statements, s)
+		return nil
+	}
+	if len(s.operands) != len(m.params) {
+		return fmt.Errorf("%s: macro %s takes %d operands", s.pos, strings.ToLower(s.op), len(m.params))
+	}
+	// Labels defined in the body are renamed for every expansion, so a
+	// macro with labels can be used more than once.
+	a.expansions++
+	args := make(map[string]string)
+	for _, text := range m.body {
+		labels, _ := splitLabels(text)
+		for _, l := range labels {
+			args[l] = fmt.Sprintf("%s.%s.%d", m.name, l, a.expansions)
+		}
+	}
+	for i, p := range m.params {
+		args[p] = s.operands[i]
+	}
+	if len(s.labels) > 0 {
+		add("DB")
+	}
+	where := func(n int) string {
+		return fmt.Sprintf("%s (macro %s, %s)", s.pos, m.name, m.pos(n))
+	}
+	return a.parse(m.body, where, args)
+}
+
+// splitLabels returns the labels at the start of text and the rest of it.
+func splitLabels(text string) ([]string, string) {
+	var labels []string
+	for {
+		i := strings.Index(text, ":")
+		if i < 0 || !isIdent(strings.TrimSpace(text[:i])) {
+			return labels, text
+		}
+		labels = append(labels, strings.TrimSpace(text[:i]))
+		text = strings.TrimSpace(text[i+1:])
+	}
+}
+
+func (a *assembler) size(s *statement) (int, error) {
+	if s.op == "DB" {
+		size := 0
+		for _, o := range s.operands {
+			if str, ok := stringLiteral(o); ok {
+				size += len(str)
+			} else {
+				size++
+			}
+		}
+		return size, nil
+	}
+
+	opcode, ok := opcodeNames[s.op]
+	if !ok {
+		return 0, fmt.Errorf("%s: unknown mnemonic %q", s.pos, s.op)
+	}
+	n, _ := arity(opcode)
+	if len(s.operands) != n {
+		return 0, fmt.Errorf("%s: %s takes %d operands", s.pos, s.op, n)
+	}
+	return n + 1, nil
+}
+
+func (a *assembler) emit(s *statement) ([]int, error) {
+	var words []int
+
+	if s.op == "DB" {
+		for _, o := range s.operands {
+			if str, ok := stringLiteral(o); ok {
+				for _, c := range str {
+					words = append(words, int(c))
+				}
+				continue
+			}
+			v, err := a.eval(o, s)
+			if err != nil {
+				return nil, err
+			}
+			words = append(words, v)
+		}
+		return words, nil
+	}
+
+	opcode := opcodeNames[s.op]
+	code := opcode
+	scale := 100
+	for k, o := range s.operands {
+		mode := modeOf(o)
+		if mode == IMMEDIATE && isTarget(opcode, k) {
+			return nil, fmt.Errorf("%s: %s cannot write to an immediate operand", s.pos, s.op)
+		}
+		code += mode * scale
+		scale *= 10
+
+		v, err := a.eval(operandExpr(o), s)
+		if err != nil {
+			return nil, err
+		}
+		words = append(words, v)
+	}
+	return append([]int{code}, words...), nil
+}
+
+// eval evaluates a sum of numbers, labels and $.
+func (a *assembler) eval(expr string, s *statement) (int, error) {
+	expr = strings.Replace(expr, " ", "", -1)
+	if expr == "" {
+		return 0, nil
+	}
+
+	total := 0
+	sign := 1
+	start := 0
+	if expr[0] == '-' || expr[0] == '+' {
+		start = 1
+		if expr[0] == '-' {
+			sign = -1
+		}
+	}
+	for i := start; i <= len(expr); i++ {
+		if i < len(expr) && expr[i] != '+' && expr[i] != '-' {
+			continue
+		}
+		term := expr[start:i]
+		var v int
+		switch {
+		case term == "$":
+			v = s.addr
+		case isIdent(term):
+			addr, ok := a.labels[term]
+			if !ok {
+				return 0, fmt.Errorf("%s: undefined label %q", s.pos, term)
+			}
+			v = addr
+		default:
+			n, err := strconv.Atoi(term)
+			if err != nil {
+				return 0, fmt.Errorf("%s: bad expression %q", s.pos, expr)
+			}
+			v = n
+		}
+		total += sign * v
+		if i < len(expr) {
+			sign = 1
+			if expr[i] == '-' {
+				sign = -1
+			}
+		}
+		start = i + 1
+	}
+	return total, nil
+}
+
+func modeOf(operand string) int {
+	switch {
+	case strings.HasPrefix(operand, "#"):
+		return IMMEDIATE
+	case strings.EqualFold(operand, "rb") ||
+		strings.HasPrefix(strings.ToLower(operand), "rb+") ||
+		strings.HasPrefix(strings.ToLower(operand), "rb-"):
+		return RELATIVE
+	}
+	return POSITION
+}
+
+func operandExpr(operand string) string {
+	switch modeOf(operand) {
+	case IMMEDIATE:
+		return operand[1:]
+	case RELATIVE:
+		return operand[2:]
+	}
+	return strings.TrimSuffix(strings.TrimPrefix(operand, "["), "]")
+}
+
+func splitOperands(text string) []string {
+	var operands []string
+	var quoted bool
+
+	start := 0
+	for i, c := range text {
+		switch {
+		case c == '"':
+			quoted = !quoted
+		case c == ',' && !quoted:
+			operands = append(operands, strings.TrimSpace(text[start:i]))
+			start = i + 1
+		}
+	}
+	if last := strings.TrimSpace(text[start:]); last != "" || len(operands) > 0 {
+		operands = append(operands, last)
+	}
+	return operands
+}
+
+func stringLiteral(operand string) (string, bool) {
+	if len(operand) < 2 || operand[0] != '"' || operand[len(operand)-1] != '"' {
+		return "", false
+	}
+	str, err := strconv.Unquote(operand)
+	if err != nil {
+		return operand[1 : len(operand)-1], true
+	}
+	return str, true
+}
+
+func stripComment(text string) string {
+	var quoted bool
+	for i, c := range text {
+		switch {
+		case c == '"':
+			quoted = !quoted
+		case c == ';' && !quoted:
+			return text[:i]
+		}
+	}
+	return text
+}
+
+// substitute replaces whole-word occurrences of macro parameters.
+func substitute(text string, args map[string]string) string {
+	var b strings.Builder
+
+	for i := 0; i < len(text); {
+		if !isIdentChar(text[i]) {
+			b.WriteByte(text[i])
+			i++
+			continue
+		}
+		j := i
+		for j < len(text) && isIdentChar(text[j]) {
+			j++
+		}
+		word := text[i:j]
+		if v, ok := args[word]; ok {
+			b.WriteString(v)
+		} else {
+			b.WriteString(word)
+		}
+		i = j
+	}
+	return b.String()
+}
+
+func isIdent(s string) bool {
+	if s == "" || (s[0] >= '0' && s[0] <= '9') {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if !isIdentChar(s[i]) {
+			return false
+		}
+	}
+	return !strings.EqualFold(s, "rb")
+}
+
+func isIdentChar(c byte) bool {
+	return c == '_' || c == '.' ||
+		(c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
@@ -0,0 +1,130 @@
+package intcode
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func runOutput(t *testing.T, program []int, input ...int) []int {
+	t.Helper()
+	var out SliceOutput
+	m := NewMachine(program)
+	if err := m.RunIO(context.Background(), NewSliceInput(input...), &out); err != nil {
+		t.Fatal(err)
+	}
+	return out.Values
+}
+
+func TestAssemble(t *testing.T) {
+	tests := []struct {
+		name  string
+		src   string
+		input []int
+		want  []int
+	}{
+		{"db", `
+        OUT  [a]
+        OUT  [a+1]
+        OUT  [a+2]
+        HLT
+a:      db "hi", 7`, nil, []int{'h', 'i', 7}},
+		{"dollar", `
+        OUT  #$
+        OUT  #$+1
+        HLT`, nil, []int{0, 3}},
+		{"modes", `
+        ARB  #x
+        ADD  #2, #3, rb+0
+        MUL  rb+0, [k], rb+1
+        OUT  rb+1
+        HLT
+k:      db 4
+x:      db 0, 0`, nil, []int{20}},
+		{"stack", `
+        ARB  #stack
+        IN   [x]
+        push [x]
+        call double
+        pop  [x]
+        OUT  [x]
+        HLT
+double: ADD  rb-2, rb-2, rb-2
+        ret
+x:      db 0
+stack:`, []int{21}, []int{42}},
+		{"macro labels", `
+macro twice v
+        ADD  #0, #2, [n]
+again:  OUT  #v
+        ADD  [n], #-1, [n]
+        JT   [n], #again
+endm
+        twice 1
+        twice 2
+        HLT
+n:      db 0`, nil, []int{1, 1, 2, 2}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := runOutput(t, assemble(t, test.src), test.input...)
+			if !reflect.DeepEqual(got, test.want) {
+				t.Fatalf("got %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+// TestAssembleHello uses the print_at macro of asm/examples/hello.asm twice.
+func TestAssembleHello(t *testing.T) {
+	src := `
+macro print_at ptr
+        ADD  ptr, #0, [load+1]
+load:   OUT  [0]
+endm
+
+        print_at [first]
+        print_at [second]
+        HLT
+first:  db text
+second: db text+1
+text:   db "ok"`
+	got := runOutput(t, assemble(t, src))
+	if want := []int{'o', 'k'}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
+
+func TestAssembleErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{"duplicate label", "a: HLT\na: HLT", `line 2: duplicate label "a"`},
+		{"unknown mnemonic", "HLT\nFOO [1]", `line 2: unknown mnemonic "FOO"`},
+		{"operand count", "ADD [1], [2]", "line 1: ADD takes 3 operands"},
+		{"undefined label", "OUT [nowhere]", `line 1: undefined label "nowhere"`},
+		{"immediate target", "ADD [1], [2], #3", "line 1: ADD cannot write to an immediate operand"},
+		{"macro without endm", "macro m\nHLT", "line 2: macro without endm"},
+		{"macro operands", "macro m x\nOUT x\nendm\nm", "line 4: macro m takes 1 operands"},
+		{"inside macro", "macro m x\nOUT x\nFOO x\nendm\nHLT\nm [1]",
+			`line 6 (macro m, line 3): unknown mnemonic "FOO"`},
+		{"label in macro", "macro m\nOUT [missing]\nendm\nm",
+			`line 4 (macro m, line 2): undefined label "missing"`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := Assemble(test.src)
+			if err == nil {
+				t.Fatalf("got no error, want %q", test.want)
+			}
+			if !strings.Contains(err.Error(), test.want) {
+				t.Fatalf("got %q, want %q", err, test.want)
+			}
+		})
+	}
+}
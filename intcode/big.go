@@ -0,0 +1,242 @@
+package intcode
+
+import (
+	"fmt"
+	"math/big"
+)
+
+// BigMachine runs a program whose memory cells are arbitrary-precision
+// integers, for programs producing values that do not fit in an int.
+// Opcodes, addresses and the relative base must still fit. It runs
+// synchronously: inputs are queued with Feed and outputs collected with
+// TakeOutput, and there is no debugger, tracing or snapshot support.
+type BigMachine struct {
+	Memory       map[int]*big.Int
+	IP           int
+	RelativeBase int
+	Halted       bool
+
+	Steps    int64
+	MaxSteps int64
+
+	pendingIn  []*big.Int
+	pendingOut []*big.Int
+}
+
+// NewBigMachine returns a big machine loaded with program.
+func NewBigMachine(program []int) *BigMachine {
+	m := BigMachine{
+		Memory: make(map[int]*big.Int),
+	}
+	for addr, v := range program {
+		if v != 0 {
+			m.Memory[addr] = big.NewInt(int64(v))
+		}
+	}
+	return &m
+}
+
+// Feed queues values to be read by input instructions.
+func (m *BigMachine) Feed(values ...*big.Int) {
+	for _, v := range values {
+		m.pendingIn = append(m.pendingIn, new(big.Int).Set(v))
+	}
+}
+
+// TakeOutput returns and clears the values output so far.
+func (m *BigMachine) TakeOutput() []*big.Int {
+	out := m.pendingOut
+	m.pendingOut = nil
+	return out
+}
+
+// Read returns a copy of the value stored at addr.
+func (m *BigMachine) Read(addr int) *big.Int {
+	return new(big.Int).Set(m.cell(addr))
+}
+
+// Write stores a copy of value at addr.
+func (m *BigMachine) Write(addr int, value *big.Int) error {
+	if addr < 0 {
+		return fmt.Errorf("%w: %v", ErrNegativeAddress, addr)
+	}
+	if addr >= DefaultMemoryLimit {
+		return fmt.Errorf("%w: %v", ErrMemoryLimit, addr)
+	}
+	m.Memory[addr] = new(big.Int).Set(value)
+	return nil
+}
+
+var bigZero = new(big.Int)
+
+func (m *BigMachine) cell(addr int) *big.Int {
+	if v, ok := m.Memory[addr]; ok {
+		return v
+	}
+	return bigZero
+}
+
+// Run executes instructions until the machine halts or faults. Running out
+// of input is a fault wrapping ErrNoInput; Feed more and Run again to go on.
+func (m *BigMachine) Run() error {
+	for !m.Halted {
+		if err := m.Step(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Step executes the single instruction at IP. On a fault IP is left on the
+// offending instruction and a *MachineError is returned.
+func (m *BigMachine) Step() error {
+	if m.Halted {
+		return nil
+	}
+
+	i := m.IP
+	if i < 0 || i >= DefaultMemoryLimit {
+		return m.fail(ErrIPOutOfBounds)
+	}
+	if m.MaxSteps > 0 && m.Steps >= m.MaxSteps {
+		return m.fail(fmt.Errorf("%w: %v", ErrStepLimit, m.MaxSteps))
+	}
+
+	code, ok := toInt(m.cell(i))
+	if !ok {
+		return m.fail(fmt.Errorf("%w: %v", ErrUnknownOpcode, m.cell(i)))
+	}
+	// Only the code cell is decoded here: parameters may not fit in an int.
+	inst := decodeCode(code, Full)
+	if inst.op == nil {
+		return m.fail(&DialectError{Dialect: Full.Name, Opcode: inst.opcode, Mode: -1})
+	}
+	n := inst.arity
+	if i+n >= DefaultMemoryLimit {
+		return m.fail(ErrIPOutOfBounds)
+	}
+
+	var param [3]*big.Int
+	var target int
+	var err, targetErr error
+	for k := 0; k < n; k++ {
+		if k == inst.badParam {
+			return m.fail(&DialectError{Dialect: Full.Name, Opcode: inst.opcode, Mode: inst.modes[k]})
+		}
+		raw := m.cell(i + 1 + k)
+		if k == inst.target && inst.modes[k] == IMMEDIATE {
+			err = ErrImmediateWrite
+		} else if k == inst.target {
+			target, targetErr = m.address(raw, inst.modes[k])
+		} else if inst.modes[k] == IMMEDIATE {
+			param[k] = raw
+		} else {
+			var addr int
+			if addr, err = m.address(raw, inst.modes[k]); err == nil {
+				param[k] = m.cell(addr)
+			}
+		}
+		if err != nil {
+			return m.fail(err)
+		}
+	}
+
+	// As on Machine, a bad target address only faults once there is a
+	// value to write, which for an input instruction needs an input.
+	switch {
+	case inst.opcode == STORE && len(m.pendingIn) == 0:
+		return m.fail(ErrNoInput)
+	case targetErr != nil:
+		return m.fail(targetErr)
+	}
+
+	switch inst.opcode {
+	case ADD:
+		err = m.Write(target, new(big.Int).Add(param[0], param[1]))
+		i += 4
+	case MULTIPLY:
+		err = m.Write(target, new(big.Int).Mul(param[0], param[1]))
+		i += 4
+	case STORE:
+		err = m.Write(target, m.pendingIn[0])
+		m.pendingIn = m.pendingIn[1:]
+		i += 2
+	case LOAD:
+		m.pendingOut = append(m.pendingOut, new(big.Int).Set(param[0]))
+		i += 2
+	case JUMP_IF_TRUE, JUMP_IF_FALSE:
+		if (param[0].Sign() != 0) == (inst.opcode == JUMP_IF_TRUE) {
+			var ok bool
+			if i, ok = toInt(param[1]); !ok {
+				return m.fail(fmt.Errorf("%w: %v", ErrIPOutOfBounds, param[1]))
+			}
+		} else {
+			i += 3
+		}
+	case LESS_THAN:
+		err = m.Write(target, big.NewInt(boolInt(param[0].Cmp(param[1]) < 0)))
+		i += 4
+	case EQUALS:
+		err = m.Write(target, big.NewInt(boolInt(param[0].Cmp(param[1]) == 0)))
+		i += 4
+	case RELATIVE_BASE:
+		d, ok := toInt(param[0])
+		if !ok {
+			return m.fail(fmt.Errorf("%w: relative base %v", ErrOverflow, param[0]))
+		}
+		m.RelativeBase += d
+		i += 2
+	case HALT:
+		m.Halted = true
+	}
+	if err != nil {
+		return m.fail(err)
+	}
+	m.IP = i
+	m.Steps++
+	return nil
+}
+
+// address returns the address a parameter in position or relative mode
+// refers to.
+func (m *BigMachine) address(raw *big.Int, mode int) (int, error) {
+	if mode == IMMEDIATE {
+		return 0, ErrImmediateWrite
+	}
+	addr, ok := toInt(raw)
+	if mode == RELATIVE {
+		addr += m.RelativeBase
+	}
+	switch {
+	case !ok || addr >= DefaultMemoryLimit:
+		return 0, fmt.Errorf("%w: %v", ErrMemoryLimit, raw)
+	case addr < 0:
+		return 0, fmt.Errorf("%w: %v", ErrNegativeAddress, addr)
+	}
+	return addr, nil
+}
+
+func (m *BigMachine) fail(err error) error {
+	e := MachineError{
+		Err:          err,
+		IP:           m.IP,
+		RelativeBase: m.RelativeBase,
+	}
+	e.Instruction, _ = toInt(m.cell(m.IP))
+	return &e
+}
+
+// toInt returns v as an int if it fits.
+func toInt(v *big.Int) (int, bool) {
+	if !v.IsInt64() || v.Int64() > int64(maxInt) || v.Int64() < int64(minInt) {
+		return 0, false
+	}
+	return int(v.Int64()), true
+}
+
+func boolInt(b bool) int64 {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,45 @@
+package intcode
+
+import (
+	"fmt"
+	"math/big"
+	"testing"
+)
+
+func TestBigMachine(t *testing.T) {
+	quine := []int{109, 1, 204, -1, 1001, 100, 1, 100, 1008, 100, 16, 101, 1006, 101, 0, 99}
+	tests := []struct {
+		name    string
+		program []int
+		want    []int
+	}{
+		{"large", []int{104, 1125899906842624, 99}, []int{1125899906842624}},
+		{"sixteen digits", []int{1102, 34915192, 34915192, 7, 4, 7, 99, 0}, []int{1219070632396864}},
+		{"quine", quine, quine},
+	}
+	for _, tt := range tests {
+		m := NewBigMachine(tt.program)
+		if err := m.Run(); err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if got := fmt.Sprint(m.TakeOutput()); got != fmt.Sprint(tt.want) {
+			t.Errorf("%s: output %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestBigMachineBeyondInt(t *testing.T) {
+	// Squares 2^40 and reads the square back through an input and an
+	// output, all beyond what a machine of ints can hold.
+	m := NewBigMachine([]int{3, 11, 2, 11, 11, 11, 4, 11, 99, 0, 0, 0})
+	m.Feed(new(big.Int).Lsh(big.NewInt(1), 40))
+	if err := m.Run(); err != nil {
+		t.Fatal(err)
+	}
+	out := m.TakeOutput()
+	want := new(big.Int).Lsh(big.NewInt(1), 80)
+	if len(out) != 1 || out[0].Cmp(want) != 0 {
+		t.Fatalf("output %v, want [%v]", out, want)
+	}
+}
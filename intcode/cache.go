@@ -0,0 +1,129 @@
+package intcode
+
+// cached is an instruction decoded once and kept by Memory until one of its
+// cells is written.
+type cached struct {
+	valid  bool
+	op     *Op
+	code   int
+	opcode int
+	arity  int
+	modes  [3]int
+	params [3]int
+	// target is the index of the parameter written to, or -1.
+	target int
+	// badParam is the index of the first parameter with a mode the
+	// dialect does not allow, or -1.
+	badParam int
+}
+
+// maxArity is the number of cells after an instruction its cache entry
+// depends on.
+const maxArity = 3
+
+// codePage holds the decoded instructions of one page. Like pages, they are
+// shared copy-on-write between a memory and its clones, so a clone starts
+// with every instruction its parent already decoded.
+type codePage [pageSize]cached
+
+// fetch returns the instruction at addr, which must be a valid address,
+// decoded for dialect d. Instructions in the dense region are cached per
+// page; others are only valid until the next fetch.
+func (mem *Memory) fetch(addr int, d *Dialect) *cached {
+	if d != mem.dialect {
+		mem.code = nil
+		mem.codeOwned = nil
+		mem.dialect = d
+	}
+	if addr >= denseLimit || mem.uncached {
+		mem.scratch = mem.decodeAt(addr, d)
+		return &mem.scratch
+	}
+	n := addr >> pageBits
+	if n < len(mem.code) && mem.code[n] != nil {
+		if c := &mem.code[n][addr&(pageSize-1)]; c.valid {
+			return c
+		}
+	}
+	c := &mem.ownCode(n)[addr&(pageSize-1)]
+	*c = mem.decodeAt(addr, d)
+	return c
+}
+
+// ownCode returns the decoded instructions of page n for modification,
+// allocating them or copying them from a clone as needed.
+func (mem *Memory) ownCode(n int) *codePage {
+	for n >= len(mem.code) {
+		mem.code = append(mem.code, nil)
+		mem.codeOwned = append(mem.codeOwned, false)
+	}
+	switch {
+	case mem.code[n] == nil:
+		mem.code[n] = new(codePage)
+	case !mem.codeOwned[n]:
+		c := *mem.code[n]
+		mem.code[n] = &c
+	default:
+		return mem.code[n]
+	}
+	mem.codeOwned[n] = true
+	return mem.code[n]
+}
+
+func (mem *Memory) decodeAt(addr int, d *Dialect) cached {
+	c := decodeCode(mem.Read(addr), d)
+	for k := 0; k < c.arity; k++ {
+		c.params[k] = mem.Read(addr + 1 + k)
+	}
+	return c
+}
+
+// decodeCode splits an instruction code the way Step executes it: the two
+// low digits are the opcode and the next three the parameter modes. Mode
+// digits past the opcode's parameters and any higher digits are ignored.
+// Parameters are left to the caller.
+func decodeCode(code int, d *Dialect) cached {
+	c := cached{
+		valid:    true,
+		code:     code,
+		target:   -1,
+		badParam: -1,
+	}
+	c.opcode = code % 100
+	if code >= 0 {
+		c.op = d.Op(c.opcode)
+	}
+	if c.op == nil {
+		return c
+	}
+	c.arity = c.op.Arity
+	if c.op.Writes {
+		c.target = c.arity - 1
+	}
+	ps := code / 100
+	for k := 0; k < 3; k++ {
+		c.modes[k] = ps % 10
+		ps /= 10
+	}
+	for k := 0; k < c.arity; k++ {
+		if !d.mode(c.modes[k]) {
+			c.badParam = k
+			break
+		}
+	}
+	return c
+}
+
+// invalidate drops the cached instructions that include addr.
+func (mem *Memory) invalidate(addr int) {
+	for a := addr - maxArity; a <= addr; a++ {
+		if a < 0 || a >= denseLimit {
+			continue
+		}
+		n := a >> pageBits
+		if n >= len(mem.code) || mem.code[n] == nil || !mem.code[n][a&(pageSize-1)].valid {
+			continue
+		}
+		mem.ownCode(n)[a&(pageSize-1)].valid = false
+	}
+}
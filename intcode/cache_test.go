@@ -0,0 +1,114 @@
+package intcode
+
+import (
+	"errors"
+	"testing"
+)
+
+// benchmarkStep measures a single step of the fib workload, restarting it
+// whenever it halts.
+func benchmarkStep(b *testing.B, uncached bool) {
+	program := fibProgram(b)
+	start := func() *Machine {
+		m := NewMachine(program)
+		m.Memory.uncached = uncached
+		m.Feed(30)
+		return m
+	}
+
+	m := start()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if m.Halted {
+			m = start()
+		}
+		if err := m.Step(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkStepCached(b *testing.B) {
+	benchmarkStep(b, false)
+}
+
+func BenchmarkStepUncached(b *testing.B) {
+	benchmarkStep(b, true)
+}
+
+func TestUncachedStep(t *testing.T) {
+	program := fibProgram(t)
+	var out [2][]int
+	for k, uncached := range []bool{false, true} {
+		m := NewMachine(program)
+		m.Memory.uncached = uncached
+		m.Feed(15)
+		if err := m.Run(nil, nil); err != nil {
+			t.Fatal(err)
+		}
+		out[k] = m.TakeOutput()
+	}
+	if len(out[0]) != 1 || len(out[1]) != 1 || out[0][0] != 610 || out[1][0] != 610 {
+		t.Fatalf("fib(15): cached %v, uncached %v, want [610]", out[0], out[1])
+	}
+}
+
+func TestCacheFarJump(t *testing.T) {
+	// Stores HLT near the top of the dense region and jumps there.
+	m := NewMachine([]int{1101, 99, 0, 1000000, 1105, 1, 1000000})
+	if err := m.Run(nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	pages := 0
+	for _, p := range m.Memory.code {
+		if p != nil {
+			pages++
+		}
+	}
+	if pages != 2 {
+		t.Fatalf("decoded %d pages, want 2", pages)
+	}
+}
+
+func TestCloneSharesCache(t *testing.T) {
+	// Reads values until it reads 0, then writes the last non-zero one.
+	m := NewMachine(assemble(t, `
+loop:   IN   [x]
+        JF   [x], #done
+        ADD  [x], #0, [last]
+        JT   #1, #loop
+done:   OUT  [last]
+        HLT
+x:      db 0
+last:   db 0`))
+	m.Feed(1, 2)
+	if _, err := m.Resume(0); !errors.Is(err, ErrNoInput) {
+		t.Fatalf("got %v, want %v", err, ErrNoInput)
+	}
+
+	c := m.Clone()
+	if c.Memory.code[0] != m.Memory.code[0] {
+		t.Fatal("clone does not share the decoded instructions")
+	}
+
+	// Making the ADD add 1 in the clone must not change the parent.
+	c.Write(7, 1)
+	if c.Memory.code[0] == m.Memory.code[0] {
+		t.Fatal("clone wrote to the shared decoded instructions")
+	}
+	c.Feed(3, 0)
+	m.Feed(5, 0)
+	for _, run := range []struct {
+		m    *Machine
+		want int
+	}{{c, 4}, {m, 5}} {
+		out, err := run.m.Resume(0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(out) != 1 || out[0] != run.want {
+			t.Fatalf("got %v, want [%d]", out, run.want)
+		}
+	}
+}
@@ -0,0 +1,265 @@
+package intcode
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Block is a basic block: instructions that always run in sequence, entered
+// only at the first and left only after the last.
+type Block struct {
+	Start int
+	End   int // address following the last instruction
+	Insts []Instruction
+	// Succs are the addresses of the blocks control can pass to.
+	Succs []int
+	// Indirect is set when the block ends with a jump whose target is read
+	// from memory and so is not known statically.
+	Indirect bool
+	// Return is set for an indirect jump through the relative base, the
+	// way subroutines return to the address pushed by their caller.
+	Return bool
+}
+
+// Last returns the instruction ending the block.
+func (b *Block) Last() Instruction {
+	return b.Insts[len(b.Insts)-1]
+}
+
+// Write is an instruction storing to a cell holding code.
+type Write struct {
+	Addr   int // address of the writing instruction
+	Target int
+}
+
+// Call is a likely subroutine call: the return address is stored relative to
+// the relative base and an unconditional jump goes to the subroutine.
+type Call struct {
+	Addr   int // address of the jump
+	Target int
+	Return int
+	// Frame is the size the subroutine's leading ARB reserves, or 0.
+	Frame int
+}
+
+// CFG is the control-flow graph of a program image.
+type CFG struct {
+	Blocks map[int]*Block
+	// Indirect lists the addresses of jumps with unknown targets.
+	Indirect []int
+	// Writes lists the position-mode stores into reachable code. Relative
+	// stores are not included; where they land is only known at run time.
+	Writes []Write
+	Calls  []Call
+}
+
+// Analyze builds the control-flow graph of the code reachable from address
+// 0, found the same way as by Compile.
+func Analyze(program []int) *CFG {
+	code := reachable(program)
+	g := CFG{
+		Blocks: make(map[int]*Block),
+	}
+
+	var addrs []int
+	cells := make(map[int]bool)
+	leaders := map[int]bool{0: true}
+	for addr, inst := range code {
+		addrs = append(addrs, addr)
+		for k := 0; k < inst.Size(); k++ {
+			cells[addr+k] = true
+		}
+		switch inst.Opcode {
+		case JUMP_IF_TRUE, JUMP_IF_FALSE:
+			if inst.Modes[1] == IMMEDIATE {
+				leaders[inst.Params[1]] = true
+			}
+			leaders[addr+inst.Size()] = true
+		case HALT:
+			leaders[addr+inst.Size()] = true
+		}
+	}
+	sort.Ints(addrs)
+
+	var b *Block
+	for _, addr := range addrs {
+		inst := code[addr]
+		if b == nil || leaders[addr] || b.End != addr {
+			if b != nil {
+				g.fallThrough(b, code)
+			}
+			b = &Block{Start: addr}
+			g.Blocks[addr] = b
+		}
+		b.Insts = append(b.Insts, inst)
+		b.End = addr + inst.Size()
+
+		for k := 0; k < inst.Arity; k++ {
+			if isTarget(inst.Opcode, k) && inst.Modes[k] == POSITION && cells[inst.Params[k]] {
+				g.Writes = append(g.Writes, Write{Addr: addr, Target: inst.Params[k]})
+			}
+		}
+	}
+	if b != nil {
+		g.fallThrough(b, code)
+	}
+
+	for _, addr := range g.Addrs() {
+		g.findCall(g.Blocks[addr], code)
+	}
+	return &g
+}
+
+// fallThrough sets the successors of b from its last instruction.
+func (g *CFG) fallThrough(b *Block, code map[int]Instruction) {
+	last := b.Last()
+	switch last.Opcode {
+	case HALT:
+		return
+	case JUMP_IF_TRUE, JUMP_IF_FALSE:
+		never := last.Modes[0] == IMMEDIATE && !always(last)
+		if !never {
+			if last.Modes[1] == IMMEDIATE {
+				b.Succs = append(b.Succs, last.Params[1])
+			} else {
+				b.Indirect = true
+				b.Return = last.Modes[1] == RELATIVE
+				g.Indirect = append(g.Indirect, last.Addr)
+			}
+		}
+		if always(last) {
+			return
+		}
+	}
+	if _, ok := code[b.End]; ok {
+		b.Succs = append(b.Succs, b.End)
+	}
+}
+
+// findCall records b as a call if it ends with an unconditional jump to an
+// immediate address after storing the address following the jump at rb+n.
+func (g *CFG) findCall(b *Block, code map[int]Instruction) {
+	last := b.Last()
+	if !always(last) || last.Modes[1] != IMMEDIATE {
+		return
+	}
+	ret := b.End
+	for _, inst := range b.Insts[:len(b.Insts)-1] {
+		if inst.Opcode != ADD && inst.Opcode != MULTIPLY || inst.Modes[2] != RELATIVE {
+			continue
+		}
+		if inst.Modes[0] != IMMEDIATE || inst.Modes[1] != IMMEDIATE {
+			continue
+		}
+		v := inst.Params[0] + inst.Params[1]
+		if inst.Opcode == MULTIPLY {
+			v = inst.Params[0] * inst.Params[1]
+		}
+		if v != ret {
+			continue
+		}
+		c := Call{Addr: last.Addr, Target: last.Params[1], Return: ret}
+		if entry, ok := code[c.Target]; ok && entry.Opcode == RELATIVE_BASE && entry.Modes[0] == IMMEDIATE {
+			c.Frame = entry.Params[0]
+		}
+		g.Calls = append(g.Calls, c)
+		return
+	}
+}
+
+// Addrs returns the start addresses of the blocks in order.
+func (g *CFG) Addrs() []int {
+	var addrs []int
+	for addr := range g.Blocks {
+		addrs = append(addrs, addr)
+	}
+	sort.Ints(addrs)
+	return addrs
+}
+
+// Subroutines returns the targets of calls in order.
+func (g *CFG) Subroutines() []int {
+	seen := make(map[int]bool)
+	var subs []int
+	for _, c := range g.Calls {
+		if !seen[c.Target] {
+			seen[c.Target] = true
+			subs = append(subs, c.Target)
+		}
+	}
+	sort.Ints(subs)
+	return subs
+}
+
+// WriteDot writes the graph in Graphviz DOT format. Calls are drawn as dashed
+// edges to the return address, indirect jumps as red blocks and blocks
+// overwritten by the program as filled ones.
+func (g *CFG) WriteDot(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	written := make(map[int]bool)
+	for _, wr := range g.Writes {
+		written[wr.Target] = true
+	}
+	subs := make(map[int]bool)
+	for _, addr := range g.Subroutines() {
+		subs[addr] = true
+	}
+
+	fmt.Fprintln(bw, "digraph intcode {")
+	fmt.Fprintln(bw, "\tnode [shape=box, fontname=monospace];")
+	for _, addr := range g.Addrs() {
+		b := g.Blocks[addr]
+		var label strings.Builder
+		if subs[addr] {
+			fmt.Fprintf(&label, "sub_%d:\\l", addr)
+		}
+		for _, inst := range b.Insts {
+			fmt.Fprintf(&label, "%d: %s\\l", inst.Addr, inst)
+		}
+
+		var attrs []string
+		if b.Indirect {
+			attrs = append(attrs, "color=red")
+		}
+		for a := b.Start; a < b.End; a++ {
+			if written[a] {
+				attrs = append(attrs, "style=filled", "fillcolor=lightyellow")
+				break
+			}
+		}
+		fmt.Fprintf(bw, "\tb%d [label=\"%s\"%s];\n", addr, label.String(), joinAttrs(attrs))
+
+		for _, s := range b.Succs {
+			if _, ok := g.Blocks[s]; ok {
+				fmt.Fprintf(bw, "\tb%d -> b%d;\n", addr, s)
+			}
+		}
+	}
+	for _, c := range g.Calls {
+		if _, ok := g.Blocks[c.Return]; ok {
+			fmt.Fprintf(bw, "\tb%d -> b%d [style=dashed, label=call];\n", g.blockOf(c.Addr), c.Return)
+		}
+	}
+	fmt.Fprintln(bw, "}")
+	return bw.Flush()
+}
+
+// blockOf returns the start of the block holding the instruction at addr.
+func (g *CFG) blockOf(addr int) int {
+	for start, b := range g.Blocks {
+		if addr >= start && addr < b.End {
+			return start
+		}
+	}
+	return -1
+}
+
+func joinAttrs(attrs []string) string {
+	if len(attrs) == 0 {
+		return ""
+	}
+	return ", " + strings.Join(attrs, ", ")
+}
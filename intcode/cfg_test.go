@@ -0,0 +1,135 @@
+package intcode
+
+import (
+	"bytes"
+	"io/ioutil"
+	"reflect"
+	"testing"
+)
+
+// subroutine calls sub with the stack macros; sub overwrites the operand of
+// the OUT its caller returns to.
+const subroutine = `
+        ARB  #stack
+        call sub
+done:   OUT  #0
+        HLT
+sub:    ARB  #2
+        ADD  #7, #0, [done+1]
+        ARB  #-2
+        ret
+stack:`
+
+type wantBlock struct {
+	start, end int
+	succs      []int
+}
+
+func TestAnalyze(t *testing.T) {
+	tests := []struct {
+		name     string
+		src      string
+		blocks   []wantBlock
+		indirect []int
+		returns  []int
+		writes   []Write
+		calls    []Call
+	}{
+		{
+			name: "branch",
+			src: `
+        IN   [x]
+        JF   [x], #zero
+        OUT  #1
+        HLT
+zero:   OUT  #0
+        HLT
+x:      db 0`,
+			blocks: []wantBlock{{0, 5, []int{8, 5}}, {5, 8, nil}, {8, 11, nil}},
+		},
+		{
+			name: "loop",
+			src: `
+loop:   IN   [x]
+        JT   [x], #loop
+        HLT
+x:      db 0`,
+			blocks: []wantBlock{{0, 5, []int{0, 5}}, {5, 6, nil}},
+		},
+		{
+			name:     "call",
+			src:      subroutine,
+			blocks:   []wantBlock{{0, 11, []int{14}}, {11, 14, nil}, {14, 27, nil}},
+			indirect: []int{24},
+			returns:  []int{14},
+			writes:   []Write{{Addr: 16, Target: 12}},
+			calls:    []Call{{Addr: 8, Target: 14, Return: 11, Frame: 2}},
+		},
+		{
+			name: "indirect",
+			src: `
+        JT   #1, [x]
+        HLT
+x:      db 0`,
+			blocks:   []wantBlock{{0, 3, nil}},
+			indirect: []int{0},
+		},
+		{
+			// Only the position-mode store is flagged: where the relative
+			// one lands depends on the relative base.
+			name: "self-modifying",
+			src: `
+        ADD  #5, #0, [n+1]
+n:      OUT  #0
+        ADD  #1, #0, rb+6
+        HLT`,
+			blocks: []wantBlock{{0, 11, nil}},
+			writes: []Write{{Addr: 0, Target: 5}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			g := Analyze(assemble(t, test.src))
+
+			var blocks []wantBlock
+			var returns []int
+			for _, addr := range g.Addrs() {
+				b := g.Blocks[addr]
+				blocks = append(blocks, wantBlock{b.Start, b.End, b.Succs})
+				if b.Return {
+					returns = append(returns, addr)
+				}
+			}
+			if !reflect.DeepEqual(blocks, test.blocks) {
+				t.Errorf("blocks: got %v, want %v", blocks, test.blocks)
+			}
+			if !reflect.DeepEqual(g.Indirect, test.indirect) {
+				t.Errorf("indirect: got %v, want %v", g.Indirect, test.indirect)
+			}
+			if !reflect.DeepEqual(returns, test.returns) {
+				t.Errorf("returns: got %v, want %v", returns, test.returns)
+			}
+			if !reflect.DeepEqual(g.Writes, test.writes) {
+				t.Errorf("writes: got %v, want %v", g.Writes, test.writes)
+			}
+			if !reflect.DeepEqual(g.Calls, test.calls) {
+				t.Errorf("calls: got %v, want %v", g.Calls, test.calls)
+			}
+		})
+	}
+}
+
+func TestWriteDot(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Analyze(assemble(t, subroutine)).WriteDot(&buf); err != nil {
+		t.Fatal(err)
+	}
+	want, err := ioutil.ReadFile("testdata/sub.dot")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := buf.String(); got != string(want) {
+		t.Fatalf("got\n%s\nwant\n%s", got, want)
+	}
+}
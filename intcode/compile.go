@@ -0,0 +1,286 @@
+package intcode
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"go/format"
+	"sort"
+)
+
+// Compiled is a program translated to Go by Compile. It runs m from m.IP
+// until the machine halts or reaches an instruction it cannot run: an
+// address that was not compiled, a fault, or a blocked input or output. That
+// instruction is left for the interpreter. It returns true when it stopped
+// because the program overwrote compiled code, after which the compiled
+// version is stale and must not be used again.
+type Compiled func(m *Machine) (modified bool)
+
+// RunCompiled is Run executing code, the compiled form of the program loaded
+// in m, and interpreting whatever code cannot handle. Compiled code neither
+// traces, profiles, counts steps against MaxSteps, checks for overflow nor
+// knows dialects other than Full, so a machine using any of these is
+// interpreted throughout.
+func (m *Machine) RunCompiled(code Compiled, input chan int, output chan int) error {
+	m.Attach(input, output)
+	interpret := m.Trace != nil || m.Profile != nil || m.MaxSteps > 0 || m.Checked ||
+		(m.Dialect != nil && m.Dialect != Full)
+	for !interpret && !m.Halted {
+		if code(m) {
+			break
+		}
+		if m.Halted {
+			break
+		}
+		if err := m.Step(); err != nil {
+			return m.stop(err)
+		}
+	}
+	return m.run(context.Background())
+}
+
+// Compile translates program into Go source for package pkg. The source
+// defines name, a Compiled function, and nameProgram, the image it was
+// compiled from. Only instructions reachable from address 0 through
+// fall-through and immediate jump targets are compiled; indirect jumps
+// elsewhere and writes to compiled instructions hand over to the
+// interpreter.
+func Compile(program []int, pkg string, name string) ([]byte, error) {
+	code := reachable(program)
+	if len(code) == 0 {
+		return nil, fmt.Errorf("%w: no instruction at 0", ErrUnknownOpcode)
+	}
+
+	var addrs []int
+	cells := make(map[int]bool)
+	for addr, inst := range code {
+		addrs = append(addrs, addr)
+		for k := 0; k < inst.Size(); k++ {
+			cells[addr+k] = true
+		}
+	}
+	sort.Ints(addrs)
+
+	g := compiler{
+		name:  name,
+		cells: cells,
+	}
+	fmt.Fprintf(&g.b, "// Code generated by intcode.Compile. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&g.b, "package %s\n\n", pkg)
+	fmt.Fprintf(&g.b, "import \"github.com/jingqiuELE/advent_code_2019/intcode\"\n\n")
+
+	fmt.Fprintf(&g.b, "var %sProgram = []int{", name)
+	for i, v := range program {
+		if i%16 == 0 {
+			g.b.WriteString("\n")
+		}
+		fmt.Fprintf(&g.b, "%d, ", v)
+	}
+	g.b.WriteString("\n}\n\n")
+
+	var cellList []int
+	for addr := range cells {
+		cellList = append(cellList, addr)
+	}
+	sort.Ints(cellList)
+	fmt.Fprintf(&g.b, "// %sCode marks the cells holding compiled instructions.\n", name)
+	fmt.Fprintf(&g.b, "var %sCode = [...]bool{", name)
+	for i, addr := range cellList {
+		if i%12 == 0 {
+			g.b.WriteString("\n")
+		}
+		fmt.Fprintf(&g.b, "%d: true, ", addr)
+	}
+	g.b.WriteString("\n}\n\n")
+
+	// The cases are generated first: mem is only declared if one of them
+	// uses it.
+	head := g.b
+	g.b = bytes.Buffer{}
+	for i, addr := range addrs {
+		inst := code[addr]
+		next := -1
+		if i+1 < len(addrs) && addrs[i+1] == addr+inst.Size() {
+			next = addrs[i+1]
+		}
+		g.instruction(inst, next)
+	}
+	cases := g.b
+
+	g.b = head
+	fmt.Fprintf(&g.b, "func %s(m *intcode.Machine) bool {\n", name)
+	if bytes.Contains(cases.Bytes(), []byte("mem.")) {
+		g.b.WriteString("mem := m.Memory\n")
+	}
+	g.b.WriteString("ip, rb := m.IP, m.RelativeBase\n")
+	g.b.WriteString("var steps int64\n")
+	g.b.WriteString("defer func() {\nm.IP, m.RelativeBase = ip, rb\nm.Steps += steps\n}()\n\n")
+	g.b.WriteString("for {\nswitch ip {\n")
+	g.b.Write(cases.Bytes())
+	g.b.WriteString("default:\nreturn false\n}\n}\n}\n")
+
+	src, err := format.Source(g.b.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("formatting generated code: %v", err)
+	}
+	return src, nil
+}
+
+// reachable decodes the instructions reachable from address 0. Return
+// addresses are jumped to indirectly, so the address following an
+// unconditional jump is taken as code too when some instruction uses it as
+// an immediate value, as calls do when they push it.
+func reachable(program []int) map[int]Instruction {
+	code := make(map[int]Instruction)
+	returns := make(map[int]bool)
+	work := []int{0}
+
+	for len(work) > 0 {
+		for len(work) > 0 {
+			addr := work[len(work)-1]
+			work = work[:len(work)-1]
+			if _, ok := code[addr]; ok || addr < 0 || addr >= len(program) {
+				continue
+			}
+			inst, err := Decode(program, addr)
+			if err != nil || addr+inst.Arity >= len(program) {
+				continue
+			}
+			code[addr] = inst
+
+			switch inst.Opcode {
+			case HALT:
+				continue
+			case JUMP_IF_TRUE, JUMP_IF_FALSE:
+				if inst.Modes[1] == IMMEDIATE {
+					work = append(work, inst.Params[1])
+				}
+				if always(inst) {
+					returns[addr+inst.Size()] = true
+					continue
+				}
+			}
+			work = append(work, addr+inst.Size())
+		}
+
+		for _, inst := range code {
+			for k := 0; k < inst.Arity; k++ {
+				v := inst.Params[k]
+				if inst.Modes[k] == IMMEDIATE && returns[v] {
+					delete(returns, v)
+					work = append(work, v)
+				}
+			}
+		}
+	}
+	return code
+}
+
+// always reports whether a conditional jump is always taken.
+func always(inst Instruction) bool {
+	if inst.Modes[0] != IMMEDIATE {
+		return false
+	}
+	return (inst.Opcode == JUMP_IF_TRUE) == (inst.Params[0] != 0)
+}
+
+type compiler struct {
+	b     bytes.Buffer
+	name  string
+	cells map[int]bool
+}
+
+// instruction emits the case for inst. next is the address of the
+// instruction that follows in the switch if execution can fall through to
+// it, or -1.
+func (g *compiler) instruction(inst Instruction, next int) {
+	end := inst.Addr + inst.Size()
+	fmt.Fprintf(&g.b, "case %d: // %s\n", inst.Addr, inst)
+
+	switch inst.Opcode {
+	case ADD, MULTIPLY, LESS_THAN, EQUALS:
+		g.operand(inst, 0, "a")
+		g.operand(inst, 1, "b")
+		switch inst.Opcode {
+		case ADD:
+			g.b.WriteString("v := a + b\n")
+		case MULTIPLY:
+			g.b.WriteString("v := a * b\n")
+		case LESS_THAN:
+			g.b.WriteString("v := 0\nif a < b {\nv = 1\n}\n")
+		case EQUALS:
+			g.b.WriteString("v := 0\nif a == b {\nv = 1\n}\n")
+		}
+		if g.store(inst, 2, end) {
+			return
+		}
+	case STORE:
+		g.b.WriteString("v, err := m.Input()\nif err != nil {\nreturn false\n}\n")
+		if g.store(inst, 0, end) {
+			return
+		}
+	case LOAD:
+		g.operand(inst, 0, "a")
+		g.b.WriteString("if err := m.Output(a); err != nil {\nreturn false\n}\n")
+	case JUMP_IF_TRUE, JUMP_IF_FALSE:
+		if always(inst) {
+			g.operand(inst, 1, "b")
+			g.b.WriteString("ip = b\nsteps++\ncontinue\n")
+			return
+		}
+		if inst.Modes[0] != IMMEDIATE {
+			g.operand(inst, 0, "a")
+			g.operand(inst, 1, "b")
+			cond := "a != 0"
+			if inst.Opcode == JUMP_IF_FALSE {
+				cond = "a == 0"
+			}
+			fmt.Fprintf(&g.b, "if %s {\nip = b\nsteps++\ncontinue\n}\n", cond)
+		}
+	case RELATIVE_BASE:
+		g.operand(inst, 0, "a")
+		g.b.WriteString("rb += a\n")
+	case HALT:
+		g.b.WriteString("m.Halted = true\nsteps++\nreturn false\n")
+		return
+	}
+
+	fmt.Fprintf(&g.b, "ip = %d\nsteps++\n", end)
+	if next >= 0 {
+		g.b.WriteString("fallthrough\n")
+	}
+}
+
+// operand emits the statements loading parameter k of inst into variable v.
+func (g *compiler) operand(inst Instruction, k int, v string) {
+	p := inst.Params[k]
+	switch inst.Modes[k] {
+	case IMMEDIATE:
+		fmt.Fprintf(&g.b, "%s := %d\n", v, p)
+		return
+	case POSITION:
+		fmt.Fprintf(&g.b, "%s, err := mem.Load(%d)\n", v, p)
+	case RELATIVE:
+		fmt.Fprintf(&g.b, "%s, err := mem.Load(rb + %d)\n", v, p)
+	}
+	g.b.WriteString("if err != nil {\nreturn false\n}\n")
+}
+
+// store emits the write of v to parameter k of inst, handing over to the
+// interpreter at end if the write hits compiled code. It returns true if the
+// write always does, ending the case.
+func (g *compiler) store(inst Instruction, k int, end int) bool {
+	p := inst.Params[k]
+	if inst.Modes[k] == POSITION {
+		fmt.Fprintf(&g.b, "if err := mem.Store(%d, v); err != nil {\nreturn false\n}\n", p)
+		if g.cells[p] {
+			fmt.Fprintf(&g.b, "ip = %d\nsteps++\nreturn true\n", end)
+			return true
+		}
+		return false
+	}
+	fmt.Fprintf(&g.b, "t := rb + %d\n", p)
+	g.b.WriteString("if err := mem.Store(t, v); err != nil {\nreturn false\n}\n")
+	fmt.Fprintf(&g.b, "if t >= 0 && t < len(%sCode) && %sCode[t] {\nip = %d\nsteps++\nreturn true\n}\n", g.name, g.name, end)
+	return false
+}
@@ -0,0 +1,1250 @@
+// Code generated by go test -run TestCompiledPrograms -update. DO NOT EDIT.
+
+package intcode_test
+
+import "github.com/jingqiuELE/advent_code_2019/intcode"
+
+var compiled1Program = []int{
+	1106, 39, 59, 203, 16, 1005, 57, 47, 7200099, 99, 1006, 49, 41, 204, 17, 31105,
+	48, 29, 20201, 3, 17, 8, 6800003, 49, 26203, 15, 51006, 82, 53, 2201, 5, 16,
+	24, 2208, 17, 9, 19, 1107, 81, 58, 22, 41005, 36, 13, 3106, 5, 22, 4,
+	60, 2400207, 7, 52, 20, 104, 16, 108, -8, 59, 12, 2002, 63, 13, 46, 7900102,
+	10, 53, 47, 99, 31, -5, 34, 32, 8, -4, -1, 0, 24, 10, 34, 9,
+	-5, 17, 15, -5,
+}
+
+// compiled1Code marks the cells holding compiled instructions.
+var compiled1Code = [...]bool{
+	0: true, 1: true, 2: true, 3: true, 4: true, 5: true, 6: true, 7: true, 47: true, 48: true, 59: true, 60: true,
+	61: true, 62: true,
+}
+
+func compiled1(m *intcode.Machine) bool {
+	mem := m.Memory
+	ip, rb := m.IP, m.RelativeBase
+	var steps int64
+	defer func() {
+		m.IP, m.RelativeBase = ip, rb
+		m.Steps += steps
+	}()
+
+	for {
+		switch ip {
+		case 0: // JF  #39, #59
+			ip = 3
+			steps++
+			fallthrough
+		case 3: // IN  rb+16
+			v, err := m.Input()
+			if err != nil {
+				return false
+			}
+			t := rb + 16
+			if err := mem.Store(t, v); err != nil {
+				return false
+			}
+			if t >= 0 && t < len(compiled1Code) && compiled1Code[t] {
+				ip = 5
+				steps++
+				return true
+			}
+			ip = 5
+			steps++
+			fallthrough
+		case 5: // JT  [57], #47
+			a, err := mem.Load(57)
+			if err != nil {
+				return false
+			}
+			b := 47
+			if a != 0 {
+				ip = b
+				steps++
+				continue
+			}
+			ip = 8
+			steps++
+		case 47: // OUT [60]
+			a, err := mem.Load(60)
+			if err != nil {
+				return false
+			}
+			if err := m.Output(a); err != nil {
+				return false
+			}
+			ip = 49
+			steps++
+		case 59: // MUL [63], rb+13, [46]
+			a, err := mem.Load(63)
+			if err != nil {
+				return false
+			}
+			b, err := mem.Load(rb + 13)
+			if err != nil {
+				return false
+			}
+			v := a * b
+			if err := mem.Store(46, v); err != nil {
+				return false
+			}
+			ip = 63
+			steps++
+		default:
+			return false
+		}
+	}
+}
+
+var compiled2Program = []int{
+	1108, 16, 2, 57, 99, 3, 34, 2102, 84, 16, 52, 2005, 14, 4, 44003, 12,
+	2107, -6, 6, 6, 5300003, 20, 2002, 44, 5, 47, 52005, 17, 0, 9003, 44, 3,
+	77, 104, 15, 1205, 17, 14, 2201, 17, 14, 67, 101201, 1, 99, 70, 4201202, 11,
+	19, 13, 1205, 1, 20, 209, 0, 22007, 37, 7, 6, 108, 85, 68, 29, 61206,
+	-2, 35, 209, 0, 99, 17, 33, -3, 32, 10, 2, 4, 5, 3, 19, 7,
+	9, 7, 24, 0, 28,
+}
+
+// compiled2Code marks the cells holding compiled instructions.
+var compiled2Code = [...]bool{
+	0: true, 1: true, 2: true, 3: true, 4: true,
+}
+
+func compiled2(m *intcode.Machine) bool {
+	mem := m.Memory
+	ip, rb := m.IP, m.RelativeBase
+	var steps int64
+	defer func() {
+		m.IP, m.RelativeBase = ip, rb
+		m.Steps += steps
+	}()
+
+	for {
+		switch ip {
+		case 0: // EQ  #16, #2, [57]
+			a := 16
+			b := 2
+			v := 0
+			if a == b {
+				v = 1
+			}
+			if err := mem.Store(57, v); err != nil {
+				return false
+			}
+			ip = 4
+			steps++
+			fallthrough
+		case 4: // HLT
+			m.Halted = true
+			steps++
+			return false
+		default:
+			return false
+		}
+	}
+}
+
+var compiled3Program = []int{
+	1205, 11, 28, 22001, 78, 10, 12, 7501006, 67, 0, 22201, -2, 5, 8, 5802101, 34,
+	14, 22, 9, 15, 5100003, 30, 204, 1, 7, 31, 11, 20, 3, 33, 204, 2,
+	203, 1, 2202, -1, 4, 65, 901207, 4, 99, 37, 41999, 203, 12, 204, 13, 1106,
+	97, 43, 2002, 14, 13, 28, 3, 39, 9, 59, 1206, 10, 61, 1201, 8, 54,
+	57, 99, 20, 1, 13, 19, 13, 32, 17, -3, 20, 18, 6, 31, 18, 23,
+	10, 16,
+}
+
+// compiled3Code marks the cells holding compiled instructions.
+var compiled3Code = [...]bool{
+	0: true, 1: true, 2: true, 3: true, 4: true, 5: true, 6: true, 28: true, 29: true, 30: true, 31: true, 32: true,
+	33: true, 34: true, 35: true, 36: true, 37: true,
+}
+
+func compiled3(m *intcode.Machine) bool {
+	mem := m.Memory
+	ip, rb := m.IP, m.RelativeBase
+	var steps int64
+	defer func() {
+		m.IP, m.RelativeBase = ip, rb
+		m.Steps += steps
+	}()
+
+	for {
+		switch ip {
+		case 0: // JT  rb+11, #28
+			a, err := mem.Load(rb + 11)
+			if err != nil {
+				return false
+			}
+			b := 28
+			if a != 0 {
+				ip = b
+				steps++
+				continue
+			}
+			ip = 3
+			steps++
+			fallthrough
+		case 3: // ADD [78], rb+10, rb+12
+			a, err := mem.Load(78)
+			if err != nil {
+				return false
+			}
+			b, err := mem.Load(rb + 10)
+			if err != nil {
+				return false
+			}
+			v := a + b
+			t := rb + 12
+			if err := mem.Store(t, v); err != nil {
+				return false
+			}
+			if t >= 0 && t < len(compiled3Code) && compiled3Code[t] {
+				ip = 7
+				steps++
+				return true
+			}
+			ip = 7
+			steps++
+		case 28: // IN  [33]
+			v, err := m.Input()
+			if err != nil {
+				return false
+			}
+			if err := mem.Store(33, v); err != nil {
+				return false
+			}
+			ip = 30
+			steps++
+			return true
+		case 30: // OUT rb+2
+			a, err := mem.Load(rb + 2)
+			if err != nil {
+				return false
+			}
+			if err := m.Output(a); err != nil {
+				return false
+			}
+			ip = 32
+			steps++
+			fallthrough
+		case 32: // IN  rb+1
+			v, err := m.Input()
+			if err != nil {
+				return false
+			}
+			t := rb + 1
+			if err := mem.Store(t, v); err != nil {
+				return false
+			}
+			if t >= 0 && t < len(compiled3Code) && compiled3Code[t] {
+				ip = 34
+				steps++
+				return true
+			}
+			ip = 34
+			steps++
+			fallthrough
+		case 34: // MUL rb-1, rb+4, [65]
+			a, err := mem.Load(rb + -1)
+			if err != nil {
+				return false
+			}
+			b, err := mem.Load(rb + 4)
+			if err != nil {
+				return false
+			}
+			v := a * b
+			if err := mem.Store(65, v); err != nil {
+				return false
+			}
+			ip = 38
+			steps++
+		default:
+			return false
+		}
+	}
+}
+
+var compiled4Program = []int{
+	108, 10, 46, 73, 11005, 8, 48, 1001, 36, 20, 63, 52005, 67, 12, 21008, 48,
+	21, 2, 3, 63, 22107, 38, 14, 11, 22007, 65, 6, 4, 203, 11, 3, 88,
+	3202108, 78, 13, 40, 21108, 18, 84, 9, 2207, 7, 17, 16, 8600209, 11, 104, 91,
+	1105, 64, 14, 9, 70, 8722007, 29, 5, -1, 2108, 90, 10, 69, 70104, 4, 7621201,
+	-1, 14, 9, 2108, 40, 9, 46, 61202, 9, 97, -2, 26299, 9, 29, 13, 11,
+	-2, -4, 6, 15, -1, 15, 5, 18, 22, 29, 7, -5,
+}
+
+// compiled4Code marks the cells holding compiled instructions.
+var compiled4Code = [...]bool{
+	0: true, 1: true, 2: true, 3: true,
+}
+
+func compiled4(m *intcode.Machine) bool {
+	mem := m.Memory
+	ip, rb := m.IP, m.RelativeBase
+	var steps int64
+	defer func() {
+		m.IP, m.RelativeBase = ip, rb
+		m.Steps += steps
+	}()
+
+	for {
+		switch ip {
+		case 0: // EQ  #10, [46], [73]
+			a := 10
+			b, err := mem.Load(46)
+			if err != nil {
+				return false
+			}
+			v := 0
+			if a == b {
+				v = 1
+			}
+			if err := mem.Store(73, v); err != nil {
+				return false
+			}
+			ip = 4
+			steps++
+		default:
+			return false
+		}
+	}
+}
+
+var compiled5Program = []int{
+	104, 21, 99, 7, 49, 1, 75, 1005, 61, 71, 5000108, 6, 49, 69, 2106, 53,
+	8, 2500009, 37, 203, 4, 1006, 44, 17, 4, 86, 22102, 83, -1, 2, 2008, 6,
+	17, 25, 2208, 11, 3, 60, 102, 75, 21, 16, 1106, 48, 58, 4601007, 52, 2,
+	24, 5822208, 11, 14, -2, 61106, 48, 58, 209, 4, 1205, 9, 26, 74009, 84, 21208,
+	14, -1, 15, 1208, 8, 80, 84, 99, 24, 15, -4, 5, 0, 29, 3, 30,
+	22, -1, 23, 20, -2, 0, 3, 28,
+}
+
+// compiled5Code marks the cells holding compiled instructions.
+var compiled5Code = [...]bool{
+	0: true, 1: true, 2: true,
+}
+
+func compiled5(m *intcode.Machine) bool {
+	ip, rb := m.IP, m.RelativeBase
+	var steps int64
+	defer func() {
+		m.IP, m.RelativeBase = ip, rb
+		m.Steps += steps
+	}()
+
+	for {
+		switch ip {
+		case 0: // OUT #21
+			a := 21
+			if err := m.Output(a); err != nil {
+				return false
+			}
+			ip = 2
+			steps++
+			fallthrough
+		case 2: // HLT
+			m.Halted = true
+			steps++
+			return false
+		default:
+			return false
+		}
+	}
+}
+
+var compiled6Program = []int{
+	22207, 6, 5, 15, 9, 65, 2108, 40, 16, 56, 203, 10, 3922107, 61, 6, 6,
+	22101, -1, 5, -2, 109, 74, 209, 8, 109, 59, 107, 56, 42, 83, 31006, 44,
+	63, 1006, 35, 53, 1105, 14, 59, 1201, 15, 35, 29, 20208, 4, 4, 3, 7,
+	16, 43, 75, 11104, 51, 1006, 1, 30, 1205, 1, 12, 9, 72, 203, 5, 102,
+	99, 48, 28, 4, 17, 99, 21, 17, 29, 16, 6, 10, 9, 34, 19, -2,
+	21, -2, -2, -1, 26, 28,
+}
+
+// compiled6Code marks the cells holding compiled instructions.
+var compiled6Code = [...]bool{
+	0: true, 1: true, 2: true, 3: true, 4: true, 5: true, 6: true, 7: true, 8: true, 9: true, 10: true, 11: true,
+}
+
+func compiled6(m *intcode.Machine) bool {
+	mem := m.Memory
+	ip, rb := m.IP, m.RelativeBase
+	var steps int64
+	defer func() {
+		m.IP, m.RelativeBase = ip, rb
+		m.Steps += steps
+	}()
+
+	for {
+		switch ip {
+		case 0: // LT  rb+6, rb+5, rb+15
+			a, err := mem.Load(rb + 6)
+			if err != nil {
+				return false
+			}
+			b, err := mem.Load(rb + 5)
+			if err != nil {
+				return false
+			}
+			v := 0
+			if a < b {
+				v = 1
+			}
+			t := rb + 15
+			if err := mem.Store(t, v); err != nil {
+				return false
+			}
+			if t >= 0 && t < len(compiled6Code) && compiled6Code[t] {
+				ip = 4
+				steps++
+				return true
+			}
+			ip = 4
+			steps++
+			fallthrough
+		case 4: // ARB [65]
+			a, err := mem.Load(65)
+			if err != nil {
+				return false
+			}
+			rb += a
+			ip = 6
+			steps++
+			fallthrough
+		case 6: // EQ  #40, rb+16, [56]
+			a := 40
+			b, err := mem.Load(rb + 16)
+			if err != nil {
+				return false
+			}
+			v := 0
+			if a == b {
+				v = 1
+			}
+			if err := mem.Store(56, v); err != nil {
+				return false
+			}
+			ip = 10
+			steps++
+			fallthrough
+		case 10: // IN  rb+10
+			v, err := m.Input()
+			if err != nil {
+				return false
+			}
+			t := rb + 10
+			if err := mem.Store(t, v); err != nil {
+				return false
+			}
+			if t >= 0 && t < len(compiled6Code) && compiled6Code[t] {
+				ip = 12
+				steps++
+				return true
+			}
+			ip = 12
+			steps++
+		default:
+			return false
+		}
+	}
+}
+
+var compiled7Program = []int{
+	1206, 3, 36, 2421201, 15, -3, 15, 3, 44, 1002, 65, 68, 3, 2008, 31, 16,
+	49, 1106, 45, 17, 9, 44, 99, 1002, 60, 24, 21, 106, 81, 35, 204, 7,
+	2201, 15, 11, 53, 71006, 28, 32, 1108, 19, 8, 60, 22002, 28, 13, 5, 201,
+	4, 59, 0, 1108, 64, 48, 50, 2701206, -1, 3, 2901205, 11, 7, 99, 501, 17,
+	50, 67, 9, 64, 99, 99, -3, 10, 31, 27, 27, 3, 10, 34, 19, 31,
+	30, 26, 14, -5, -3, 6,
+}
+
+// compiled7Code marks the cells holding compiled instructions.
+var compiled7Code = [...]bool{
+	0: true, 1: true, 2: true,
+}
+
+func compiled7(m *intcode.Machine) bool {
+	mem := m.Memory
+	ip, rb := m.IP, m.RelativeBase
+	var steps int64
+	defer func() {
+		m.IP, m.RelativeBase = ip, rb
+		m.Steps += steps
+	}()
+
+	for {
+		switch ip {
+		case 0: // JF  rb+3, #36
+			a, err := mem.Load(rb + 3)
+			if err != nil {
+				return false
+			}
+			b := 36
+			if a == 0 {
+				ip = b
+				steps++
+				continue
+			}
+			ip = 3
+			steps++
+		default:
+			return false
+		}
+	}
+}
+
+var compiled8Program = []int{
+	1205, 3, 9, 1006, 41, 36, 1205, 0, 64, 9601205, 17, 14, 9, 29, 1107, -4,
+	59, 83, 3, 46, 20207, 14, 39, 8, 1108, 53, 14, 65, 9, 43, 22008, 64,
+	16, 12, 3, 54, 39003, 61, 204, 13, 109, 2, 1205, 6, 28, 2002, 41, 13,
+	14, 24108, 88, 45, 12, 73003, 43, 3, 38, 22102, 48, 9, 2, 6106, 90, 0,
+	7, 4, 24, 61, 99, 12, 8, 12, 26, 22, -4, 21, 7, 7, 30, 8,
+	16, 11, 28, 26, 5,
+}
+
+// compiled8Code marks the cells holding compiled instructions.
+var compiled8Code = [...]bool{
+	0: true, 1: true, 2: true, 3: true, 4: true, 5: true, 6: true, 7: true, 8: true, 64: true, 65: true, 66: true,
+	67: true, 68: true,
+}
+
+func compiled8(m *intcode.Machine) bool {
+	mem := m.Memory
+	ip, rb := m.IP, m.RelativeBase
+	var steps int64
+	defer func() {
+		m.IP, m.RelativeBase = ip, rb
+		m.Steps += steps
+	}()
+
+	for {
+		switch ip {
+		case 0: // JT  rb+3, #9
+			a, err := mem.Load(rb + 3)
+			if err != nil {
+				return false
+			}
+			b := 9
+			if a != 0 {
+				ip = b
+				steps++
+				continue
+			}
+			ip = 3
+			steps++
+			fallthrough
+		case 3: // JF  [41], #36
+			a, err := mem.Load(41)
+			if err != nil {
+				return false
+			}
+			b := 36
+			if a == 0 {
+				ip = b
+				steps++
+				continue
+			}
+			ip = 6
+			steps++
+			fallthrough
+		case 6: // JT  rb+0, #64
+			a, err := mem.Load(rb + 0)
+			if err != nil {
+				return false
+			}
+			b := 64
+			if a != 0 {
+				ip = b
+				steps++
+				continue
+			}
+			ip = 9
+			steps++
+		case 64: // LT  [4], [24], [61]
+			a, err := mem.Load(4)
+			if err != nil {
+				return false
+			}
+			b, err := mem.Load(24)
+			if err != nil {
+				return false
+			}
+			v := 0
+			if a < b {
+				v = 1
+			}
+			if err := mem.Store(61, v); err != nil {
+				return false
+			}
+			ip = 68
+			steps++
+			fallthrough
+		case 68: // HLT
+			m.Halted = true
+			steps++
+			return false
+		default:
+			return false
+		}
+	}
+}
+
+var compiled9Program = []int{
+	99, 20002, 44, 42, -1, 4, 21, 2101, 78, 12, 4, 9, 53, 5501106, 70, 63,
+	203, 5, 108, -1, 1, 8, 9, 45, 3, 74, 9, 13, 2101, 9, 15, 54,
+	1105, 46, 1, 1005, 28, 26, 3, 52, 8129104, 31, 1105, 58, 22, 2002, 65, -1,
+	67, 4, 0, 8001006, 45, 40, 1602007, 12, 6, 56, 3, 28, 2005, 65, 17, 99,
+	1, 24, 24, 0, 12, 20, 14, 6, 32, 17, 19, -1, 32, 27, 9, 14,
+}
+
+// compiled9Code marks the cells holding compiled instructions.
+var compiled9Code = [...]bool{
+	0: true,
+}
+
+func compiled9(m *intcode.Machine) bool {
+	ip, rb := m.IP, m.RelativeBase
+	var steps int64
+	defer func() {
+		m.IP, m.RelativeBase = ip, rb
+		m.Steps += steps
+	}()
+
+	for {
+		switch ip {
+		case 0: // HLT
+			m.Halted = true
+			steps++
+			return false
+		default:
+			return false
+		}
+	}
+}
+
+var compiled10Program = []int{
+	3, 49, 2108, 47, 6, 48, 204, 16, 2208, -2, 5, 37, 3700203, 1, 4, 51,
+	9, 29, 202, 9, 78, 35, 2100203, 3, 203, 13, 99, 2101, 67, 10, 30, 1106,
+	8, 2, 21202, 0, 0, -2, 1005, 3, 52, 3, 80, 84209, 6, 2102, 55, 8,
+	25, 1605, 14, 65, 4702108, 37, 13, 12, 1005, 21, 16, 21002, 28, 20, 0, 9500203,
+	6, 99, 6, 30, 27, -5, 31, 6, 23, 4, 34, 4, 25, 17, 16, 3,
+	19, 14,
+}
+
+// compiled10Code marks the cells holding compiled instructions.
+var compiled10Code = [...]bool{
+	0: true, 1: true, 2: true, 3: true, 4: true, 5: true, 6: true, 7: true, 8: true, 9: true, 10: true, 11: true,
+}
+
+func compiled10(m *intcode.Machine) bool {
+	mem := m.Memory
+	ip, rb := m.IP, m.RelativeBase
+	var steps int64
+	defer func() {
+		m.IP, m.RelativeBase = ip, rb
+		m.Steps += steps
+	}()
+
+	for {
+		switch ip {
+		case 0: // IN  [49]
+			v, err := m.Input()
+			if err != nil {
+				return false
+			}
+			if err := mem.Store(49, v); err != nil {
+				return false
+			}
+			ip = 2
+			steps++
+			fallthrough
+		case 2: // EQ  #47, rb+6, [48]
+			a := 47
+			b, err := mem.Load(rb + 6)
+			if err != nil {
+				return false
+			}
+			v := 0
+			if a == b {
+				v = 1
+			}
+			if err := mem.Store(48, v); err != nil {
+				return false
+			}
+			ip = 6
+			steps++
+			fallthrough
+		case 6: // OUT rb+16
+			a, err := mem.Load(rb + 16)
+			if err != nil {
+				return false
+			}
+			if err := m.Output(a); err != nil {
+				return false
+			}
+			ip = 8
+			steps++
+			fallthrough
+		case 8: // EQ  rb-2, rb+5, [37]
+			a, err := mem.Load(rb + -2)
+			if err != nil {
+				return false
+			}
+			b, err := mem.Load(rb + 5)
+			if err != nil {
+				return false
+			}
+			v := 0
+			if a == b {
+				v = 1
+			}
+			if err := mem.Store(37, v); err != nil {
+				return false
+			}
+			ip = 12
+			steps++
+		default:
+			return false
+		}
+	}
+}
+
+var compiled11Program = []int{
+	4, 16, 9, 82, 3, 78, 22202, 7, 0, 0, 8600108, 63, 61, 14, 75003, 69,
+	1106, 2, 10, 1005, 22, 16, 1106, 21, 6, 21107, 76, 39, 8, 1006, 14, 10,
+	108, 53, 71, 56, 9622201, 3, 0, 10, 20208, 11, 14, 1, 21007, 40, 80, -1,
+	1702, 13, 25, 57, 3600109, 66, 203, 14, 22201, 3, 4, 3, 13009, 19, 42009, 65,
+	1105, 38, 44, 1201, 16, -4, 7, 59399, 5, 8, 26, 34, 28, 11, 26, 8,
+	7, 10, 2, 34, 17, 9, 16, 34,
+}
+
+// compiled11Code marks the cells holding compiled instructions.
+var compiled11Code = [...]bool{
+	0: true, 1: true, 2: true, 3: true, 4: true, 5: true, 6: true, 7: true, 8: true, 9: true,
+}
+
+func compiled11(m *intcode.Machine) bool {
+	mem := m.Memory
+	ip, rb := m.IP, m.RelativeBase
+	var steps int64
+	defer func() {
+		m.IP, m.RelativeBase = ip, rb
+		m.Steps += steps
+	}()
+
+	for {
+		switch ip {
+		case 0: // OUT [16]
+			a, err := mem.Load(16)
+			if err != nil {
+				return false
+			}
+			if err := m.Output(a); err != nil {
+				return false
+			}
+			ip = 2
+			steps++
+			fallthrough
+		case 2: // ARB [82]
+			a, err := mem.Load(82)
+			if err != nil {
+				return false
+			}
+			rb += a
+			ip = 4
+			steps++
+			fallthrough
+		case 4: // IN  [78]
+			v, err := m.Input()
+			if err != nil {
+				return false
+			}
+			if err := mem.Store(78, v); err != nil {
+				return false
+			}
+			ip = 6
+			steps++
+			fallthrough
+		case 6: // MUL rb+7, rb+0, rb+0
+			a, err := mem.Load(rb + 7)
+			if err != nil {
+				return false
+			}
+			b, err := mem.Load(rb + 0)
+			if err != nil {
+				return false
+			}
+			v := a * b
+			t := rb + 0
+			if err := mem.Store(t, v); err != nil {
+				return false
+			}
+			if t >= 0 && t < len(compiled11Code) && compiled11Code[t] {
+				ip = 10
+				steps++
+				return true
+			}
+			ip = 10
+			steps++
+		default:
+			return false
+		}
+	}
+}
+
+var compiled12Program = []int{
+	104, 71, 1105, 74, 25, 6, 27, 52, 22007, 6, 15, 14, 1800001, 51, 49, 72,
+	204, 9, 1206, 10, 35, 22208, 1, -1, 17, 1107, 93, -2, 43, 204, 13, 102,
+	58, 4, 38, 1202, 4, 62, 6, 2002, 16, 3, 17, 99, 3, 1, 3, 48,
+	99, 209, 7, 20202, 17, 78, 5, 1006, 77, 21, 207, 3, 61, 39, 20108, 73,
+	62, 6, 41205, 4, 2, 99, 24, 5, 7, 19, 2, 23, 3, 26, 28, -3,
+	23, 2, 24, 19, 23, 32,
+}
+
+// compiled12Code marks the cells holding compiled instructions.
+var compiled12Code = [...]bool{
+	0: true, 1: true, 2: true, 3: true, 4: true, 25: true, 26: true, 27: true, 28: true, 29: true, 30: true, 31: true,
+	32: true, 33: true, 34: true, 35: true, 36: true, 37: true, 38: true, 39: true, 40: true, 41: true, 42: true, 43: true,
+}
+
+func compiled12(m *intcode.Machine) bool {
+	mem := m.Memory
+	ip, rb := m.IP, m.RelativeBase
+	var steps int64
+	defer func() {
+		m.IP, m.RelativeBase = ip, rb
+		m.Steps += steps
+	}()
+
+	for {
+		switch ip {
+		case 0: // OUT #71
+			a := 71
+			if err := m.Output(a); err != nil {
+				return false
+			}
+			ip = 2
+			steps++
+			fallthrough
+		case 2: // JT  #74, #25
+			b := 25
+			ip = b
+			steps++
+			continue
+		case 25: // LT  #93, #-2, [43]
+			a := 93
+			b := -2
+			v := 0
+			if a < b {
+				v = 1
+			}
+			if err := mem.Store(43, v); err != nil {
+				return false
+			}
+			ip = 29
+			steps++
+			return true
+		case 29: // OUT rb+13
+			a, err := mem.Load(rb + 13)
+			if err != nil {
+				return false
+			}
+			if err := m.Output(a); err != nil {
+				return false
+			}
+			ip = 31
+			steps++
+			fallthrough
+		case 31: // MUL #58, [4], [38]
+			a := 58
+			b, err := mem.Load(4)
+			if err != nil {
+				return false
+			}
+			v := a * b
+			if err := mem.Store(38, v); err != nil {
+				return false
+			}
+			ip = 35
+			steps++
+			return true
+		case 35: // MUL rb+4, #62, [6]
+			a, err := mem.Load(rb + 4)
+			if err != nil {
+				return false
+			}
+			b := 62
+			v := a * b
+			if err := mem.Store(6, v); err != nil {
+				return false
+			}
+			ip = 39
+			steps++
+			fallthrough
+		case 39: // MUL [16], rb+3, [17]
+			a, err := mem.Load(16)
+			if err != nil {
+				return false
+			}
+			b, err := mem.Load(rb + 3)
+			if err != nil {
+				return false
+			}
+			v := a * b
+			if err := mem.Store(17, v); err != nil {
+				return false
+			}
+			ip = 43
+			steps++
+			fallthrough
+		case 43: // HLT
+			m.Halted = true
+			steps++
+			return false
+		default:
+			return false
+		}
+	}
+}
+
+var compiled13Program = []int{
+	208, 4, 16, 50, 21201, 6, 77, 17, 209, 4, 47209, 3, 20202, 12, 44, 1,
+	1002, 54, 94, 13, 36104, 41, 20101, 95, 57, 0, 21208, 6, 80, 3, 106, -7,
+	23, 24004, 81, 7, 82, 4, 35, 1102, 95, 80, 15, 1108, 50, 64, 84, 1205,
+	5, 67, 104, 55, 6, 5, 61, 104, 53, 3, 19, 1, 58, 6, 4, 22108,
+	91, -1, 7, 3, 56, 1205, 10, 33, 2725899, 28, 4, 9, -3, 6, 14, 14,
+	26, 10, 3, 20, 21, 33, 20, 14, 19,
+}
+
+// compiled13Code marks the cells holding compiled instructions.
+var compiled13Code = [...]bool{
+	0: true, 1: true, 2: true, 3: true, 4: true, 5: true, 6: true, 7: true, 8: true, 9: true,
+}
+
+func compiled13(m *intcode.Machine) bool {
+	mem := m.Memory
+	ip, rb := m.IP, m.RelativeBase
+	var steps int64
+	defer func() {
+		m.IP, m.RelativeBase = ip, rb
+		m.Steps += steps
+	}()
+
+	for {
+		switch ip {
+		case 0: // EQ  rb+4, [16], [50]
+			a, err := mem.Load(rb + 4)
+			if err != nil {
+				return false
+			}
+			b, err := mem.Load(16)
+			if err != nil {
+				return false
+			}
+			v := 0
+			if a == b {
+				v = 1
+			}
+			if err := mem.Store(50, v); err != nil {
+				return false
+			}
+			ip = 4
+			steps++
+			fallthrough
+		case 4: // ADD rb+6, #77, rb+17
+			a, err := mem.Load(rb + 6)
+			if err != nil {
+				return false
+			}
+			b := 77
+			v := a + b
+			t := rb + 17
+			if err := mem.Store(t, v); err != nil {
+				return false
+			}
+			if t >= 0 && t < len(compiled13Code) && compiled13Code[t] {
+				ip = 8
+				steps++
+				return true
+			}
+			ip = 8
+			steps++
+			fallthrough
+		case 8: // ARB rb+4
+			a, err := mem.Load(rb + 4)
+			if err != nil {
+				return false
+			}
+			rb += a
+			ip = 10
+			steps++
+		default:
+			return false
+		}
+	}
+}
+
+var compiled14Program = []int{
+	109, 18, 203, 9, 2207, 6, 16, 76, 9, 61, 99, 6901105, 70, 0, 1006, 78,
+	10, 7701106, -3, 11, 7, 8, 6, 73, 1006, 32, 51, 3, 71, 671005, 2, 27,
+	209, 13, 77109, -5, 204, 4, 108, 90, 18, 43, 4001105, 16, 10, 1006, 58, 51,
+	1205, 11, 38, 3, 74, 22202, 12, 4, 6, 109, 44, 6420108, 24, 37, 7, 99,
+	24, 32, -4, 10, 15, 7, 5, 12, 33, -5, -4, 10, -4, 33, 7, 4,
+}
+
+// compiled14Code marks the cells holding compiled instructions.
+var compiled14Code = [...]bool{
+	0: true, 1: true, 2: true, 3: true, 4: true, 5: true, 6: true, 7: true, 8: true, 9: true, 10: true,
+}
+
+func compiled14(m *intcode.Machine) bool {
+	mem := m.Memory
+	ip, rb := m.IP, m.RelativeBase
+	var steps int64
+	defer func() {
+		m.IP, m.RelativeBase = ip, rb
+		m.Steps += steps
+	}()
+
+	for {
+		switch ip {
+		case 0: // ARB #18
+			a := 18
+			rb += a
+			ip = 2
+			steps++
+			fallthrough
+		case 2: // IN  rb+9
+			v, err := m.Input()
+			if err != nil {
+				return false
+			}
+			t := rb + 9
+			if err := mem.Store(t, v); err != nil {
+				return false
+			}
+			if t >= 0 && t < len(compiled14Code) && compiled14Code[t] {
+				ip = 4
+				steps++
+				return true
+			}
+			ip = 4
+			steps++
+			fallthrough
+		case 4: // LT  rb+6, rb+16, [76]
+			a, err := mem.Load(rb + 6)
+			if err != nil {
+				return false
+			}
+			b, err := mem.Load(rb + 16)
+			if err != nil {
+				return false
+			}
+			v := 0
+			if a < b {
+				v = 1
+			}
+			if err := mem.Store(76, v); err != nil {
+				return false
+			}
+			ip = 8
+			steps++
+			fallthrough
+		case 8: // ARB [61]
+			a, err := mem.Load(61)
+			if err != nil {
+				return false
+			}
+			rb += a
+			ip = 10
+			steps++
+			fallthrough
+		case 10: // HLT
+			m.Halted = true
+			steps++
+			return false
+		default:
+			return false
+		}
+	}
+}
+
+var compiled15Program = []int{
+	99, 2008, 25, 10, 49, 204, 12, 1006, 84, 30, 1205, 7, 28, 108, 34, 25,
+	10, 203, 4, 2008, 58, 8, 53, 4, 2, 1105, 41, 5, 9, 64, 1005, 24,
+	44, 2102, 44, 9, 68, 20102, -9, 46, 6, 1206, 10, 55, 109, 43, 20002, 84,
+	75, 0, 4, 27, 1206, -1, 7, 2007, 46, 13, 15, 22002, 25, 8, 9, 99,
+	101, 78, 24, 5, 99, 14, 5, 19, 28, 27, 15, 6, 11, 23, 4, 30,
+	20, 33, -2, -3, 0,
+}
+
+// compiled15Code marks the cells holding compiled instructions.
+var compiled15Code = [...]bool{
+	0: true,
+}
+
+func compiled15(m *intcode.Machine) bool {
+	ip, rb := m.IP, m.RelativeBase
+	var steps int64
+	defer func() {
+		m.IP, m.RelativeBase = ip, rb
+		m.Steps += steps
+	}()
+
+	for {
+		switch ip {
+		case 0: // HLT
+			m.Halted = true
+			steps++
+			return false
+		default:
+			return false
+		}
+	}
+}
+
+var compiled16Program = []int{
+	20001, 42, 28, 12, 3, 8, 1007, 5, 30, 48, 21007, 25, 82, 15, 1105, 26,
+	61, 2101, 33, 12, 5, 22208, 3, 6, 7, 208, 17, 54, 48, 19009, 76, 1120101,
+	90, 15, 1, 2, 54, 71, 66, 1106, 19, 47, 5301006, 25, 45, 204, 16, 3,
+	65, 2101, 57, 14, 61, 1105, 17, 10, 203, 15, 1005, 78, 39, 81106, 35, 64,
+	1208, 1, 33, 74, 3201006, 30, 45, 99, 99, 29, 4, 8, 28, 0, 2, 13,
+	1, 17, 9, 1, 12, 11, 11, 7, 16,
+}
+
+// compiled16Code marks the cells holding compiled instructions.
+var compiled16Code = [...]bool{
+	0: true, 1: true, 2: true, 3: true, 4: true, 5: true, 6: true, 7: true, 8: true, 9: true, 10: true, 11: true,
+	12: true, 13: true, 14: true, 15: true, 16: true,
+}
+
+func compiled16(m *intcode.Machine) bool {
+	mem := m.Memory
+	ip, rb := m.IP, m.RelativeBase
+	var steps int64
+	defer func() {
+		m.IP, m.RelativeBase = ip, rb
+		m.Steps += steps
+	}()
+
+	for {
+		switch ip {
+		case 0: // ADD [42], [28], rb+12
+			a, err := mem.Load(42)
+			if err != nil {
+				return false
+			}
+			b, err := mem.Load(28)
+			if err != nil {
+				return false
+			}
+			v := a + b
+			t := rb + 12
+			if err := mem.Store(t, v); err != nil {
+				return false
+			}
+			if t >= 0 && t < len(compiled16Code) && compiled16Code[t] {
+				ip = 4
+				steps++
+				return true
+			}
+			ip = 4
+			steps++
+			fallthrough
+		case 4: // IN  [8]
+			v, err := m.Input()
+			if err != nil {
+				return false
+			}
+			if err := mem.Store(8, v); err != nil {
+				return false
+			}
+			ip = 6
+			steps++
+			return true
+		case 6: // LT  [5], #30, [48]
+			a, err := mem.Load(5)
+			if err != nil {
+				return false
+			}
+			b := 30
+			v := 0
+			if a < b {
+				v = 1
+			}
+			if err := mem.Store(48, v); err != nil {
+				return false
+			}
+			ip = 10
+			steps++
+			fallthrough
+		case 10: // LT  [25], #82, rb+15
+			a, err := mem.Load(25)
+			if err != nil {
+				return false
+			}
+			b := 82
+			v := 0
+			if a < b {
+				v = 1
+			}
+			t := rb + 15
+			if err := mem.Store(t, v); err != nil {
+				return false
+			}
+			if t >= 0 && t < len(compiled16Code) && compiled16Code[t] {
+				ip = 14
+				steps++
+				return true
+			}
+			ip = 14
+			steps++
+			fallthrough
+		case 14: // JT  #26, #61
+			b := 61
+			ip = b
+			steps++
+			continue
+		default:
+			return false
+		}
+	}
+}
+
+var compiledPrograms = map[int64]intcode.Compiled{
+	1:  compiled1,
+	2:  compiled2,
+	3:  compiled3,
+	4:  compiled4,
+	5:  compiled5,
+	6:  compiled6,
+	7:  compiled7,
+	8:  compiled8,
+	9:  compiled9,
+	10: compiled10,
+	11: compiled11,
+	12: compiled12,
+	13: compiled13,
+	14: compiled14,
+	15: compiled15,
+	16: compiled16,
+}
+
+var compiledImages = map[int64][]int{
+	1:  compiled1Program,
+	2:  compiled2Program,
+	3:  compiled3Program,
+	4:  compiled4Program,
+	5:  compiled5Program,
+	6:  compiled6Program,
+	7:  compiled7Program,
+	8:  compiled8Program,
+	9:  compiled9Program,
+	10: compiled10Program,
+	11: compiled11Program,
+	12: compiled12Program,
+	13: compiled13Program,
+	14: compiled14Program,
+	15: compiled15Program,
+	16: compiled16Program,
+}
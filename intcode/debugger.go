@@ -0,0 +1,369 @@
+package intcode
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ErrQuit is returned by Debugger.Run when the user quits the session.
+var ErrQuit = errors.New("debugger quit")
+
+const debuggerHelp = `commands:
+  s, step [n]        execute n instructions (default 1)
+  c, continue        run until a breakpoint, watchpoint, halt or fault
+  in                 run until the next instruction reading input
+  out                run until an output has been written
+  b, break addr      set a breakpoint
+  d, delete addr     remove a breakpoint or watchpoint
+  w, watch addr      stop when the cell at addr changes
+  l, list [addr] [n] disassemble n instructions (default: 8 from ip)
+  x addr [n]         examine n memory cells (default 1)
+  set addr value     write value to memory
+  ip [addr]          show or move the instruction pointer
+  rb [value]         show or set the relative base
+  info               show registers, breakpoints and watchpoints
+  detach             leave the debugger and let the program run
+  q, quit            stop the program
+`
+
+// Debugger is an interactive front end to a Machine. It replaces Machine.Run
+// and keeps using the channels of the program it is attached to, so any of
+// the daily I/O loops can be debugged by starting it in place of Run.
+type Debugger struct {
+	Machine     *Machine
+	Breakpoints map[int]bool
+	Watchpoints map[int]int
+
+	in  *bufio.Scanner
+	out io.Writer
+}
+
+// NewDebugger returns a debugger for m reading commands from in.
+func NewDebugger(m *Machine, in io.Reader, out io.Writer) *Debugger {
+	return &Debugger{
+		Machine:     m,
+		Breakpoints: make(map[int]bool),
+		Watchpoints: make(map[int]int),
+		in:          bufio.NewScanner(in),
+		out:         out,
+	}
+}
+
+// DebugProgram is RunProgram under a debugger driven from the terminal.
+func DebugProgram(program []int, input chan int, output chan int) error {
+	return NewDebugger(NewMachine(program), os.Stdin, os.Stderr).Run(input, output)
+}
+
+// Run attaches the machine to input and output and hands control to the
+// user. Like Machine.Run it closes output when the program stops.
+func (d *Debugger) Run(input chan int, output chan int) error {
+	m := d.Machine
+	m.Attach(input, output)
+
+	d.where()
+	for {
+		if m.Halted {
+			fmt.Fprintln(d.out, "program halted")
+			return m.stop(nil)
+		}
+
+		fmt.Fprint(d.out, "(icdb) ")
+		if !d.in.Scan() {
+			return m.stop(ErrQuit)
+		}
+		fields := strings.Fields(d.in.Text())
+		if len(fields) == 0 {
+			continue
+		}
+
+		err := d.command(fields[0], fields[1:])
+		switch {
+		case err == errDetach:
+			return m.Run(input, output)
+		case err == ErrQuit:
+			return m.stop(ErrQuit)
+		case isFault(err):
+			fmt.Fprintln(d.out, err)
+			return m.stop(err)
+		case err != nil:
+			fmt.Fprintln(d.out, err)
+		}
+	}
+}
+
+// Session hands control of a machine driven with Feed and NextOutput to the
+// user. Unlike Run it connects no channels: the session ends without error
+// when the program halts, needs input that has not been fed, or the user
+// detaches, leaving the machine where it stopped for the caller to go on.
+func (d *Debugger) Session() error {
+	m := d.Machine
+
+	d.where()
+	for !m.Halted {
+		fmt.Fprint(d.out, "(icdb) ")
+		if !d.in.Scan() {
+			return ErrQuit
+		}
+		fields := strings.Fields(d.in.Text())
+		if len(fields) == 0 {
+			continue
+		}
+
+		err := d.command(fields[0], fields[1:])
+		switch {
+		case err == errDetach:
+			return nil
+		case err == ErrQuit:
+			return ErrQuit
+		case errors.Is(err, ErrNoInput):
+			fmt.Fprintln(d.out, "waiting for input, leaving the debugger")
+			return nil
+		case isFault(err):
+			fmt.Fprintln(d.out, err)
+			return err
+		case err != nil:
+			fmt.Fprintln(d.out, err)
+		}
+	}
+	fmt.Fprintln(d.out, "program halted")
+	return nil
+}
+
+var errDetach = errors.New("detach")
+
+func isFault(err error) bool {
+	var e *MachineError
+	return errors.As(err, &e)
+}
+
+func (d *Debugger) command(cmd string, args []string) error {
+	m := d.Machine
+
+	nums := make([]int, len(args))
+	for i, a := range args {
+		n, err := strconv.Atoi(a)
+		if err != nil {
+			return fmt.Errorf("bad number %q", a)
+		}
+		nums[i] = n
+	}
+
+	switch cmd {
+	case "h", "help":
+		fmt.Fprint(d.out, debuggerHelp)
+	case "s", "step":
+		n := 1
+		if len(nums) > 0 {
+			n = nums[0]
+		}
+		for k := 0; k < n && !m.Halted; k++ {
+			if err := d.step(); err != nil {
+				return err
+			}
+		}
+		d.where()
+	case "c", "continue":
+		return d.until(nil, nil)
+	case "in":
+		return d.until(func(inst Instruction) bool {
+			return inst.Opcode == STORE
+		}, nil)
+	case "out":
+		return d.until(nil, func(inst Instruction) bool {
+			return inst.Opcode == LOAD
+		})
+	case "b", "break":
+		if len(nums) != 1 {
+			return errors.New("usage: break addr")
+		}
+		d.Breakpoints[nums[0]] = true
+	case "d", "delete":
+		if len(nums) != 1 {
+			return errors.New("usage: delete addr")
+		}
+		delete(d.Breakpoints, nums[0])
+		delete(d.Watchpoints, nums[0])
+	case "w", "watch":
+		if len(nums) != 1 {
+			return errors.New("usage: watch addr")
+		}
+		d.Watchpoints[nums[0]] = m.Read(nums[0])
+	case "l", "list":
+		addr, n := m.IP, 8
+		if len(nums) > 0 {
+			addr = nums[0]
+		}
+		if len(nums) > 1 {
+			n = nums[1]
+		}
+		d.list(addr, n)
+	case "x":
+		if len(nums) == 0 {
+			return errors.New("usage: x addr [n]")
+		}
+		n := 1
+		if len(nums) > 1 {
+			n = nums[1]
+		}
+		for k := 0; k < n; k++ {
+			fmt.Fprintf(d.out, "[%d] = %d\n", nums[0]+k, m.Read(nums[0]+k))
+		}
+	case "set":
+		if len(nums) != 2 {
+			return errors.New("usage: set addr value")
+		}
+		if err := m.Write(nums[0], nums[1]); err != nil {
+			return err
+		}
+		if _, ok := d.Watchpoints[nums[0]]; ok {
+			d.Watchpoints[nums[0]] = nums[1]
+		}
+	case "ip":
+		if len(nums) > 0 {
+			m.IP = nums[0]
+		}
+		d.where()
+	case "rb":
+		if len(nums) > 0 {
+			m.RelativeBase = nums[0]
+		}
+		fmt.Fprintf(d.out, "rb = %d\n", m.RelativeBase)
+	case "info":
+		d.info()
+	case "detach":
+		return errDetach
+	case "q", "quit":
+		return ErrQuit
+	default:
+		return fmt.Errorf("unknown command %q, try help", cmd)
+	}
+	return nil
+}
+
+// step executes one instruction and reports watched cells that changed.
+func (d *Debugger) step() error {
+	m := d.Machine
+
+	inst, err := m.Memory.Decode(m.IP)
+	if c, ok := m.in.(ChanInput); ok && err == nil && inst.Opcode == STORE && len(m.pendingIn) == 0 && len(c) == 0 {
+		fmt.Fprintln(d.out, "waiting for input...")
+	}
+	if err := m.Step(); err != nil {
+		return err
+	}
+	if d.checkWatchpoints() {
+		return errWatch
+	}
+	return nil
+}
+
+var errWatch = errors.New("watchpoint")
+
+// until runs until a breakpoint or watchpoint is hit, the next instruction
+// satisfies before, or the instruction just executed satisfies after.
+func (d *Debugger) until(before func(Instruction) bool, after func(Instruction) bool) error {
+	m := d.Machine
+	first := true
+
+	for !m.Halted {
+		inst, _ := m.Memory.Decode(m.IP)
+		if !first {
+			if d.Breakpoints[m.IP] {
+				fmt.Fprintf(d.out, "breakpoint at %d\n", m.IP)
+				break
+			}
+			if before != nil && before(inst) {
+				break
+			}
+		}
+		first = false
+
+		err := d.step()
+		if err == errWatch {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if after != nil && after(inst) {
+			break
+		}
+	}
+	d.where()
+	return nil
+}
+
+func (d *Debugger) checkWatchpoints() bool {
+	changed := false
+	for addr, old := range d.Watchpoints {
+		v := d.Machine.Read(addr)
+		if v != old {
+			fmt.Fprintf(d.out, "watch [%d]: %d -> %d\n", addr, old, v)
+			d.Watchpoints[addr] = v
+			changed = true
+		}
+	}
+	return changed
+}
+
+// where shows the instruction about to be executed.
+func (d *Debugger) where() {
+	m := d.Machine
+	if m.Halted {
+		fmt.Fprintf(d.out, "halted at %d\n", m.IP)
+		return
+	}
+	fmt.Fprintf(d.out, "=> %s\n", d.format(m.IP))
+}
+
+func (d *Debugger) list(addr int, n int) {
+	for k := 0; k < n; k++ {
+		marker := "  "
+		if addr == d.Machine.IP {
+			marker = "=>"
+		} else if d.Breakpoints[addr] {
+			marker = " *"
+		}
+		fmt.Fprintf(d.out, "%s %s\n", marker, d.format(addr))
+
+		inst, err := d.Machine.Memory.Decode(addr)
+		if err != nil {
+			addr++
+		} else {
+			addr += inst.Size()
+		}
+	}
+}
+
+func (d *Debugger) format(addr int) string {
+	inst, err := d.Machine.Memory.Decode(addr)
+	if err != nil {
+		return fmt.Sprintf("%6d: %-4s%d", addr, "db", d.Machine.Read(addr))
+	}
+	return Line{Addr: addr, Inst: &inst}.String()
+}
+
+func (d *Debugger) info() {
+	m := d.Machine
+	fmt.Fprintf(d.out, "ip = %d, rb = %d, halted = %v\n", m.IP, m.RelativeBase, m.Halted)
+
+	var addrs []int
+	for addr := range d.Breakpoints {
+		addrs = append(addrs, addr)
+	}
+	sort.Ints(addrs)
+	fmt.Fprintf(d.out, "breakpoints: %v\n", addrs)
+
+	addrs = nil
+	for addr := range d.Watchpoints {
+		addrs = append(addrs, addr)
+	}
+	sort.Ints(addrs)
+	fmt.Fprintf(d.out, "watchpoints: %v\n", addrs)
+}
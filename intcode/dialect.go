@@ -0,0 +1,217 @@
+package intcode
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Op is an instruction of a Dialect.
+type Op struct {
+	Opcode int
+	Name   string
+	Arity  int
+	// Writes marks the last parameter as an address written to.
+	Writes bool
+	// Exec runs the instruction at ip. param holds the parameter values,
+	// or for a written parameter its address. Exec returns the address of
+	// the next instruction.
+	Exec func(m *Machine, ip int, param [3]int) (next int, err error)
+}
+
+// Dialect is a set of instructions and parameter modes a Machine runs. The
+// default, Full, is the complete instruction set; Day2 and Day5 are the
+// subsets of the puzzles that introduced them. Other dialects are built with
+// Subset and Register. Traces, the assembler and the disassembler only know
+// the full instruction set.
+type Dialect struct {
+	Name  string
+	ops   [100]*Op
+	modes [3]bool
+}
+
+// DialectError reports an opcode or a parameter mode that is not part of the
+// machine's dialect. It matches ErrUnknownOpcode or ErrInvalidMode with
+// errors.Is.
+type DialectError struct {
+	Dialect string
+	Opcode  int
+	// Mode is the rejected mode, or -1 if the opcode was rejected.
+	Mode int
+}
+
+func (e *DialectError) Error() string {
+	if e.Mode < 0 {
+		return fmt.Sprintf("%v: %v (dialect %s)", ErrUnknownOpcode, e.Opcode, e.Dialect)
+	}
+	return fmt.Sprintf("%v: %v for opcode %v (dialect %s)", ErrInvalidMode, e.Mode, e.Opcode, e.Dialect)
+}
+
+func (e *DialectError) Is(target error) bool {
+	if e.Mode < 0 {
+		return target == ErrUnknownOpcode
+	}
+	return target == ErrInvalidMode
+}
+
+var (
+	Full = fullDialect()
+	// Day2 has only ADD, MULTIPLY and HALT with position mode parameters.
+	Day2 = Full.Subset("day2", []int{POSITION}, ADD, MULTIPLY, HALT)
+	// Day5 adds I/O, jumps and comparisons, and immediate mode.
+	Day5 = Full.Subset("day5", []int{POSITION, IMMEDIATE},
+		ADD, MULTIPLY, STORE, LOAD, JUMP_IF_TRUE, JUMP_IF_FALSE, LESS_THAN, EQUALS, HALT)
+)
+
+// NewDialect returns an empty dialect allowing the given parameter modes.
+func NewDialect(name string, modes ...int) *Dialect {
+	d := Dialect{
+		Name: name,
+	}
+	for _, mode := range modes {
+		if mode < 0 || mode >= len(d.modes) {
+			panic(fmt.Sprintf("intcode: unknown parameter mode %v", mode))
+		}
+		d.modes[mode] = true
+	}
+	return &d
+}
+
+// Register adds op to the dialect, replacing any op with the same opcode.
+// It panics if the opcode does not fit in two digits or the op has more
+// than three parameters.
+func (d *Dialect) Register(op Op) {
+	if op.Opcode < 0 || op.Opcode >= len(d.ops) || op.Arity < 0 || op.Arity > 3 || (op.Writes && op.Arity == 0) {
+		panic(fmt.Sprintf("intcode: cannot register opcode %v with %v parameters", op.Opcode, op.Arity))
+	}
+	d.ops[op.Opcode] = &op
+}
+
+// Op returns the op registered for opcode, or nil.
+func (d *Dialect) Op(opcode int) *Op {
+	if opcode < 0 || opcode >= len(d.ops) {
+		return nil
+	}
+	return d.ops[opcode]
+}
+
+// Ops returns the registered ops by opcode.
+func (d *Dialect) Ops() []Op {
+	var ops []Op
+	for _, op := range d.ops {
+		if op != nil {
+			ops = append(ops, *op)
+		}
+	}
+	sort.Slice(ops, func(i, j int) bool {
+		return ops[i].Opcode < ops[j].Opcode
+	})
+	return ops
+}
+
+// Subset returns a dialect named name with the given modes and the ops of d
+// for opcodes.
+func (d *Dialect) Subset(name string, modes []int, opcodes ...int) *Dialect {
+	s := NewDialect(name, modes...)
+	for _, opcode := range opcodes {
+		if op := d.Op(opcode); op != nil {
+			s.Register(*op)
+		}
+	}
+	return s
+}
+
+// mode reports whether the dialect allows parameter mode.
+func (d *Dialect) mode(mode int) bool {
+	return mode >= 0 && mode < len(d.modes) && d.modes[mode]
+}
+
+func fullDialect() *Dialect {
+	d := NewDialect("full", POSITION, IMMEDIATE, RELATIVE)
+	for _, op := range []Op{
+		{ADD, "ADD", 3, true, execAdd},
+		{MULTIPLY, "MUL", 3, true, execMultiply},
+		{STORE, "IN", 1, true, execInput},
+		{LOAD, "OUT", 1, false, execOutput},
+		{JUMP_IF_TRUE, "JT", 2, false, execJumpIfTrue},
+		{JUMP_IF_FALSE, "JF", 2, false, execJumpIfFalse},
+		{LESS_THAN, "LT", 3, true, execLessThan},
+		{EQUALS, "EQ", 3, true, execEquals},
+		{RELATIVE_BASE, "ARB", 1, false, execRelativeBase},
+		{HALT, "HLT", 0, false, execHalt},
+	} {
+		d.Register(op)
+	}
+	return d
+}
+
+func execAdd(m *Machine, ip int, param [3]int) (int, error) {
+	v := param[0] + param[1]
+	if m.Checked {
+		var err error
+		if v, err = addChecked(param[0], param[1]); err != nil {
+			return ip, err
+		}
+	}
+	return ip + 4, m.store(param[2], v)
+}
+
+func execMultiply(m *Machine, ip int, param [3]int) (int, error) {
+	v := param[0] * param[1]
+	if m.Checked {
+		var err error
+		if v, err = mulChecked(param[0], param[1]); err != nil {
+			return ip, err
+		}
+	}
+	return ip + 4, m.store(param[2], v)
+}
+
+func execInput(m *Machine, ip int, param [3]int) (int, error) {
+	value, err := m.Input()
+	if err != nil {
+		return ip, err
+	}
+	return ip + 2, m.store(param[0], value)
+}
+
+func execOutput(m *Machine, ip int, param [3]int) (int, error) {
+	return ip + 2, m.Output(param[0])
+}
+
+func execJumpIfTrue(m *Machine, ip int, param [3]int) (int, error) {
+	if param[0] != 0 {
+		return param[1], nil
+	}
+	return ip + 3, nil
+}
+
+func execJumpIfFalse(m *Machine, ip int, param [3]int) (int, error) {
+	if param[0] == 0 {
+		return param[1], nil
+	}
+	return ip + 3, nil
+}
+
+func execLessThan(m *Machine, ip int, param [3]int) (int, error) {
+	if param[0] < param[1] {
+		return ip + 4, m.store(param[2], 1)
+	}
+	return ip + 4, m.store(param[2], 0)
+}
+
+func execEquals(m *Machine, ip int, param [3]int) (int, error) {
+	if param[0] == param[1] {
+		return ip + 4, m.store(param[2], 1)
+	}
+	return ip + 4, m.store(param[2], 0)
+}
+
+func execRelativeBase(m *Machine, ip int, param [3]int) (int, error) {
+	m.RelativeBase += param[0]
+	return ip + 2, nil
+}
+
+func execHalt(m *Machine, ip int, param [3]int) (int, error) {
+	m.Halted = true
+	return ip, nil
+}
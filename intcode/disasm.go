@@ -0,0 +1,187 @@
+package intcode
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var errNotExecuted = errors.New("not executed")
+
+// Mnemonic returns the assembler name of opcode, or "" if it is unknown.
+func Mnemonic(opcode int) string {
+	if op := Full.Op(opcode); op != nil {
+		return op.Name
+	}
+	return ""
+}
+
+// Instruction is a single decoded instruction.
+type Instruction struct {
+	Addr   int
+	Code   int
+	Opcode int
+	Modes  [3]int
+	Params [3]int
+	Arity  int
+}
+
+// Size returns the number of memory cells taken by the instruction.
+func (inst Instruction) Size() int {
+	return inst.Arity + 1
+}
+
+// Words returns the raw memory cells of the instruction.
+func (inst Instruction) Words() []int {
+	words := []int{inst.Code}
+	return append(words, inst.Params[:inst.Arity]...)
+}
+
+func (inst Instruction) String() string {
+	var params []string
+	for k := 0; k < inst.Arity; k++ {
+		params = append(params, FormatParam(inst.Params[k], inst.Modes[k]))
+	}
+	if len(params) == 0 {
+		return Mnemonic(inst.Opcode)
+	}
+	return fmt.Sprintf("%-4s%s", Mnemonic(inst.Opcode), strings.Join(params, ", "))
+}
+
+// FormatParam renders a parameter as [12], #12 or rb+12 for position,
+// immediate and relative mode.
+func FormatParam(value int, mode int) string {
+	switch mode {
+	case POSITION:
+		return fmt.Sprintf("[%d]", value)
+	case IMMEDIATE:
+		return fmt.Sprintf("#%d", value)
+	case RELATIVE:
+		if value < 0 {
+			return fmt.Sprintf("rb%d", value)
+		}
+		return fmt.Sprintf("rb+%d", value)
+	}
+	return fmt.Sprintf("?%d", value)
+}
+
+// Decode decodes the instruction at addr of a program image.
+func Decode(program []int, addr int) (Instruction, error) {
+	return decode(func(a int) int {
+		if a < 0 || a >= len(program) {
+			return 0
+		}
+		return program[a]
+	}, addr)
+}
+
+// Decode decodes the instruction stored at addr.
+func (mem *Memory) Decode(addr int) (Instruction, error) {
+	return decode(mem.Read, addr)
+}
+
+func decode(read func(int) int, addr int) (Instruction, error) {
+	inst := Instruction{
+		Addr: addr,
+		Code: read(addr),
+	}
+	if inst.Code < 0 {
+		return inst, fmt.Errorf("%w: %v", ErrUnknownOpcode, inst.Code)
+	}
+
+	inst.Opcode = inst.Code % 100
+	n, ok := arity(inst.Opcode)
+	if !ok {
+		return inst, fmt.Errorf("%w: %v", ErrUnknownOpcode, inst.Opcode)
+	}
+	inst.Arity = n
+
+	ps := inst.Code / 100
+	for k := 0; k < 3; k++ {
+		inst.Modes[k] = ps % 10
+		ps /= 10
+	}
+	if ps != 0 {
+		return inst, fmt.Errorf("%w: %v", ErrInvalidMode, inst.Code/100)
+	}
+
+	for k := 0; k < 3; k++ {
+		mode := inst.Modes[k]
+		if k >= n {
+			if mode != POSITION {
+				return inst, fmt.Errorf("%w: %v", ErrInvalidMode, mode)
+			}
+			continue
+		}
+		if mode != POSITION && mode != IMMEDIATE && mode != RELATIVE {
+			return inst, fmt.Errorf("%w: %v", ErrInvalidMode, mode)
+		}
+		if mode == IMMEDIATE && isTarget(inst.Opcode, k) {
+			return inst, ErrImmediateWrite
+		}
+		inst.Params[k] = read(addr + 1 + k)
+	}
+	return inst, nil
+}
+
+// Line is one line of a disassembly listing. Data lines have a nil Inst.
+type Line struct {
+	Addr  int
+	Words []int
+	Inst  *Instruction
+}
+
+func (l Line) String() string {
+	if l.Inst != nil {
+		return fmt.Sprintf("%6d: %s", l.Addr, l.Inst)
+	}
+	var words []string
+	for _, w := range l.Words {
+		words = append(words, fmt.Sprint(w))
+	}
+	return fmt.Sprintf("%6d: %-4s%s", l.Addr, "db", strings.Join(words, ", "))
+}
+
+const dataPerLine = 8
+
+// Disassemble decodes a program image into listing lines. Without a trace
+// every address that decodes to a valid instruction is taken as code. When
+// executed holds the instruction addresses seen in a recorded run, only
+// those are decoded and everything else is listed as data.
+func Disassemble(program []int, executed map[int]bool) []Line {
+	var lines []Line
+	var data *Line
+
+	flush := func() {
+		if data != nil {
+			lines = append(lines, *data)
+			data = nil
+		}
+	}
+
+	for addr := 0; addr < len(program); {
+		inst, err := Decode(program, addr)
+		if executed != nil && !executed[addr] {
+			err = errNotExecuted
+		}
+		if err != nil || addr+inst.Arity >= len(program) {
+			if data == nil || len(data.Words) == dataPerLine {
+				flush()
+				data = &Line{Addr: addr}
+			}
+			data.Words = append(data.Words, program[addr])
+			addr++
+			continue
+		}
+
+		flush()
+		lines = append(lines, Line{
+			Addr:  addr,
+			Words: inst.Words(),
+			Inst:  &inst,
+		})
+		addr += inst.Size()
+	}
+	flush()
+	return lines
+}
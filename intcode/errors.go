@@ -0,0 +1,50 @@
+package intcode
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrUnknownOpcode   = errors.New("unknown opcode")
+	ErrInvalidMode     = errors.New("invalid parameter mode")
+	ErrImmediateWrite  = errors.New("write in immediate mode")
+	ErrNegativeAddress = errors.New("negative address")
+	ErrMemoryLimit     = errors.New("memory limit exceeded")
+	ErrIPOutOfBounds   = errors.New("instruction pointer out of bounds")
+	ErrInputClosed     = errors.New("input closed")
+	ErrNoInput         = errors.New("no input available")
+	ErrHalted          = errors.New("machine halted")
+	ErrSnapshotVersion = errors.New("unsupported snapshot version")
+	ErrStepLimit       = errors.New("step limit reached")
+	ErrOverflow        = errors.New("integer overflow")
+	ErrUnknownDialect  = errors.New("unknown dialect")
+)
+
+// MachineError describes a fault raised while executing an instruction. It
+// wraps one of the Err* values above, so callers can test it with errors.Is.
+type MachineError struct {
+	Err          error
+	IP           int
+	RelativeBase int
+	Instruction  int
+}
+
+func (e *MachineError) Error() string {
+	return fmt.Sprintf("%v at ip=%v (instruction=%v, relative base=%v)",
+		e.Err, e.IP, e.Instruction, e.RelativeBase)
+}
+
+func (e *MachineError) Unwrap() error {
+	return e.Err
+}
+
+func (m *Machine) fail(err error) error {
+	e := MachineError{
+		Err:          err,
+		IP:           m.IP,
+		RelativeBase: m.RelativeBase,
+	}
+	e.Instruction = m.Memory.Read(m.IP)
+	return &e
+}
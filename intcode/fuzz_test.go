@@ -0,0 +1,490 @@
+package intcode_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"go/format"
+	"io/ioutil"
+	"math/big"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/jingqiuELE/advent_code_2019/intcode"
+)
+
+var update = flag.Bool("update", false, "regenerate the compiled programs of compiled_test.go")
+
+// How a run ended. Every implementation is reduced to these so that they can
+// be compared with the reference.
+const (
+	halted         = "halted"
+	stepLimit      = "step limit"
+	noInput        = "out of input"
+	badOpcode      = "unknown opcode"
+	badMode        = "invalid mode"
+	immediateWrite = "immediate write"
+	badAddress     = "negative address"
+	overLimit      = "memory limit"
+	badIP          = "ip out of bounds"
+)
+
+const (
+	memoryLimit = intcode.DefaultMemoryLimit
+	// imageLimit bounds the memory compared between runs.
+	imageLimit = 1 << 20
+
+	// programSize is the number of instructions of a generated program.
+	programSize = 24
+	maxSteps    = 2000
+	// compiledSeeds is the number of seeds, from 1, whose programs are
+	// compiled into compiled_test.go.
+	compiledSeeds = 16
+)
+
+type outcome struct {
+	Kind    string
+	IP      int
+	Outputs []int
+	Image   []int
+}
+
+func (o outcome) String() string {
+	return fmt.Sprintf("%s at ip=%d, outputs=%v, %d memory cells", o.Kind, o.IP, o.Outputs, len(o.Image))
+}
+
+// diff describes the first difference between two outcomes, or returns "".
+func diff(got outcome, want outcome) string {
+	if got.String() != want.String() {
+		return fmt.Sprintf("want: %v\ngot:  %v", want, got)
+	}
+	for addr := range want.Image {
+		if got.Image[addr] != want.Image[addr] {
+			return fmt.Sprintf("memory [%d] = %d, want %d", addr, got.Image[addr], want.Image[addr])
+		}
+	}
+	return ""
+}
+
+// fuzzCase is a generated program with its input and the outcome of the
+// reference.
+type fuzzCase struct {
+	seed    int64
+	program []int
+	input   []int
+	want    outcome
+}
+
+type implementation struct {
+	name string
+	// run returns the outcome of the case, or false if the implementation
+	// cannot run it.
+	run func(t testing.TB, c fuzzCase) (outcome, bool)
+}
+
+var implementations = []implementation{
+	{"run", runChannels},
+	{"resume", runResume},
+	{"io", runIO},
+	{"snapshot", runSnapshot},
+	{"clone", runClone},
+	{"replay", runReplay},
+	{"compiled", runCompiled},
+	{"big", runBig},
+}
+
+// FuzzMachine runs random programs on every implementation and compares the
+// outcomes with the reference interpreter. The programs of the first seeds
+// are also run compiled.
+func FuzzMachine(f *testing.F) {
+	for seed := int64(1); seed <= 200; seed++ {
+		rng := rand.New(rand.NewSource(seed))
+		input := make([]byte, rng.Intn(8))
+		rng.Read(input)
+		f.Add(seed, input)
+	}
+	f.Fuzz(func(t *testing.T, seed int64, data []byte) {
+		c := fuzzCase{
+			seed:    seed,
+			program: generate(rand.New(rand.NewSource(seed)), programSize),
+		}
+		for _, b := range data {
+			c.input = append(c.input, int(b)-10)
+		}
+		c.want = runReference(c.program, c.input, maxSteps)
+
+		for _, impl := range implementations {
+			got, ok := impl.run(t, c)
+			if !ok {
+				continue
+			}
+			if d := diff(got, c.want); d != "" {
+				t.Fatalf("divergence in %s (seed %d)\nprogram: %v\ninput:   %v\n%s\n\n%s",
+					impl.name, seed, c.program, c.input, d, listing(c.program))
+			}
+		}
+	})
+}
+
+func listing(program []int) string {
+	var b strings.Builder
+	for _, line := range intcode.Disassemble(program, nil) {
+		fmt.Fprintln(&b, line)
+	}
+	return b.String()
+}
+
+// generate returns a random program of n instructions followed by some data.
+// Operands stay near the program, jumps mostly go to instruction starts, so
+// most programs run a while before they halt, fault or loop. Some codes get
+// junk digits: modes past the opcode's parameters, digits above the modes or
+// modes that do not exist.
+func generate(rng *rand.Rand, n int) []int {
+	opcodes := []int{
+		intcode.ADD, intcode.MULTIPLY, intcode.STORE, intcode.LOAD,
+		intcode.JUMP_IF_TRUE, intcode.JUMP_IF_FALSE, intcode.LESS_THAN,
+		intcode.EQUALS, intcode.RELATIVE_BASE,
+	}
+
+	var insts []intcode.Instruction
+	var starts []int
+	addr := 0
+	for i := 0; i < n; i++ {
+		inst := intcode.Instruction{Addr: addr, Opcode: opcodes[rng.Intn(len(opcodes))]}
+		if i == n-1 || rng.Intn(20) == 0 {
+			inst.Opcode = intcode.HALT
+		}
+		inst.Arity = map[int]int{1: 3, 2: 3, 3: 1, 4: 1, 5: 2, 6: 2, 7: 3, 8: 3, 9: 1, 99: 0}[inst.Opcode]
+		insts = append(insts, inst)
+		starts = append(starts, addr)
+		addr += inst.Size()
+	}
+	size := addr + 16
+
+	var program []int
+	for _, inst := range insts {
+		code := inst.Opcode
+		scale := 100
+		var params []int
+		for k := 0; k < inst.Arity; k++ {
+			target := (k == 2 && inst.Arity == 3) || inst.Opcode == intcode.STORE
+			jump := k == 1 && (inst.Opcode == intcode.JUMP_IF_TRUE || inst.Opcode == intcode.JUMP_IF_FALSE)
+
+			mode := rng.Intn(3)
+			if target && mode == intcode.IMMEDIATE {
+				mode = intcode.POSITION
+			}
+			if jump && rng.Intn(4) > 0 {
+				mode = intcode.IMMEDIATE
+			}
+
+			var v int
+			switch {
+			case jump && mode == intcode.IMMEDIATE:
+				v = starts[rng.Intn(len(starts))]
+			case mode == intcode.IMMEDIATE:
+				v = rng.Intn(110) - 10
+			case mode == intcode.POSITION:
+				v = rng.Intn(size)
+			case mode == intcode.RELATIVE:
+				v = rng.Intn(20) - 2
+			}
+			if rng.Intn(50) == 0 {
+				mode = 3 + rng.Intn(7)
+			}
+			code += mode * scale
+			scale *= 10
+			params = append(params, v)
+		}
+		if rng.Intn(6) == 0 {
+			for ; scale <= 10000; scale *= 10 {
+				code += rng.Intn(10) * scale
+			}
+		}
+		if rng.Intn(12) == 0 {
+			code += 100000 * (1 + rng.Intn(99))
+		}
+		program = append(program, code)
+		program = append(program, params...)
+	}
+	for len(program) < size {
+		program = append(program, rng.Intn(40)-5)
+	}
+	return program
+}
+
+func kindOf(halt bool, err error) string {
+	kinds := []struct {
+		err  error
+		kind string
+	}{
+		{intcode.ErrStepLimit, stepLimit},
+		{intcode.ErrNoInput, noInput},
+		{intcode.ErrInputClosed, noInput},
+		{intcode.ErrUnknownOpcode, badOpcode},
+		{intcode.ErrInvalidMode, badMode},
+		{intcode.ErrImmediateWrite, immediateWrite},
+		{intcode.ErrNegativeAddress, badAddress},
+		{intcode.ErrMemoryLimit, overLimit},
+		{intcode.ErrIPOutOfBounds, badIP},
+	}
+	if err == nil && halt {
+		return halted
+	}
+	for _, k := range kinds {
+		if errors.Is(err, k.err) {
+			return k.kind
+		}
+	}
+	return fmt.Sprint(err)
+}
+
+func result(m *intcode.Machine, err error, outputs []int) (outcome, bool) {
+	o := outcome{
+		Kind:    kindOf(m.Halted, err),
+		IP:      m.IP,
+		Outputs: outputs,
+		Image:   m.Memory.Image(),
+	}
+	return o, true
+}
+
+func newMachine(program []int) *intcode.Machine {
+	m := intcode.NewMachine(program)
+	m.MaxSteps = maxSteps
+	return m
+}
+
+func runChannels(t testing.TB, c fuzzCase) (outcome, bool) {
+	m := newMachine(c.program)
+	in := make(chan int, len(c.input))
+	for _, v := range c.input {
+		in <- v
+	}
+	close(in)
+	out := make(chan int, maxSteps)
+
+	err := m.Run(in, out)
+	var outputs []int
+	for v := range out {
+		outputs = append(outputs, v)
+	}
+	return result(m, err, outputs)
+}
+
+func runResume(t testing.TB, c fuzzCase) (outcome, bool) {
+	m := newMachine(c.program)
+	m.Feed(c.input...)
+	outputs, err := m.Resume(0)
+	return result(m, err, outputs)
+}
+
+func runIO(t testing.TB, c fuzzCase) (outcome, bool) {
+	m := newMachine(c.program)
+	out := &intcode.SliceOutput{}
+	err := m.RunIO(context.Background(), intcode.NewSliceInput(c.input...), out)
+	return result(m, err, out.Values)
+}
+
+// half runs m for half of the program length in steps, the point where the
+// snapshot and clone runs switch machines.
+func half(m *intcode.Machine, program []int) error {
+	for k := 0; k < len(program)/2 && !m.Halted; k++ {
+		if err := m.Step(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func runSnapshot(t testing.TB, c fuzzCase) (outcome, bool) {
+	m := newMachine(c.program)
+	m.Feed(c.input...)
+	if err := half(m, c.program); err != nil {
+		return result(m, err, m.TakeOutput())
+	}
+
+	var buf bytes.Buffer
+	if _, err := m.Snapshot().WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	s, err := intcode.ReadSnapshot(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := newMachine(nil)
+	r.Restore(s)
+	r.Steps = m.Steps
+	outputs, err := r.Resume(0)
+	return result(r, err, outputs)
+}
+
+func runClone(t testing.TB, c fuzzCase) (outcome, bool) {
+	m := newMachine(c.program)
+	m.Feed(c.input...)
+	if err := half(m, c.program); err != nil {
+		return result(m, err, m.TakeOutput())
+	}
+
+	clone := m.Clone()
+	// Writes to the original must not show through to the clone.
+	for addr := range c.program {
+		m.Write(addr, 0)
+	}
+	outputs, err := clone.Resume(0)
+	return result(clone, err, outputs)
+}
+
+// runReplay traces a run and replays the trace. The outcome is the state of
+// the replaying machine.
+func runReplay(t testing.TB, c fuzzCase) (outcome, bool) {
+	m := newMachine(c.program)
+	m.Feed(c.input...)
+	var buf bytes.Buffer
+	tw, err := intcode.NewTraceWriter(&buf, m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.Trace = tw
+	_, runErr := m.Resume(0)
+	if err := tw.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	rp, err := intcode.NewReplay(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var outputs []int
+	for _, s := range rp.Steps {
+		if v, ok := s.Output(); ok {
+			outputs = append(outputs, v)
+		}
+	}
+	if err := rp.Seek(len(rp.Steps)); err != nil {
+		return outcome{Kind: fmt.Sprint(err)}, true
+	}
+	return result(rp.Machine, runErr, outputs)
+}
+
+// runCompiled runs the compiled form of the program, if there is one.
+// Compiled code does not stop at a step limit, so only runs ending before
+// the limit are compared.
+func runCompiled(t testing.TB, c fuzzCase) (outcome, bool) {
+	code, ok := compiledPrograms[c.seed]
+	if !ok || c.want.Kind == stepLimit {
+		return outcome{}, false
+	}
+	m := intcode.NewMachine(c.program)
+	in := make(chan int, len(c.input))
+	for _, v := range c.input {
+		in <- v
+	}
+	close(in)
+	out := make(chan int, maxSteps)
+
+	err := m.RunCompiled(code, in, out)
+	var outputs []int
+	for v := range out {
+		outputs = append(outputs, v)
+	}
+	return result(m, err, outputs)
+}
+
+// runBig runs the program on a BigMachine. Its values do not wrap around, so
+// only programs that do not overflow an int are compared.
+func runBig(t testing.TB, c fuzzCase) (outcome, bool) {
+	checked := newMachine(c.program)
+	checked.Checked = true
+	checked.Feed(c.input...)
+	if _, err := checked.Resume(0); errors.Is(err, intcode.ErrOverflow) {
+		return outcome{}, false
+	}
+
+	m := intcode.NewBigMachine(c.program)
+	m.MaxSteps = maxSteps
+	for _, v := range c.input {
+		m.Feed(big.NewInt(int64(v)))
+	}
+	err := m.Run()
+
+	o := outcome{
+		Kind: kindOf(m.Halted, err),
+		IP:   m.IP,
+	}
+	for _, v := range m.TakeOutput() {
+		o.Outputs = append(o.Outputs, int(v.Int64()))
+	}
+	top := -1
+	for addr, v := range m.Memory {
+		if v.Sign() != 0 && addr > top && addr < imageLimit {
+			top = addr
+		}
+	}
+	o.Image = make([]int, top+1)
+	for addr := range o.Image {
+		o.Image[addr] = int(m.Read(addr).Int64())
+	}
+	return o, true
+}
+
+// TestCompiledPrograms checks that compiled_test.go holds the programs
+// generate returns for its seeds. With -update it compiles them again.
+func TestCompiledPrograms(t *testing.T) {
+	if *update {
+		writeCompiled(t, "compiled_test.go")
+		return
+	}
+	for seed := int64(1); seed <= compiledSeeds; seed++ {
+		program := generate(rand.New(rand.NewSource(seed)), programSize)
+		if got, ok := compiledImages[seed]; ok && fmt.Sprint(got) != fmt.Sprint(program) {
+			t.Fatalf("seed %d: compiled_test.go is stale, regenerate it with -update", seed)
+		}
+	}
+	if len(compiledPrograms) == 0 {
+		t.Fatal("no compiled programs, regenerate compiled_test.go with -update")
+	}
+}
+
+func writeCompiled(t *testing.T, path string) {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "// Code generated by go test -run TestCompiledPrograms -update. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&b, "package intcode_test\n\n")
+	fmt.Fprintf(&b, "import \"github.com/jingqiuELE/advent_code_2019/intcode\"\n\n")
+
+	var seeds []int64
+	for seed := int64(1); seed <= compiledSeeds; seed++ {
+		program := generate(rand.New(rand.NewSource(seed)), programSize)
+		src, err := intcode.Compile(program, "intcode_test", fmt.Sprintf("compiled%d", seed))
+		if err != nil {
+			// The first instruction is junk: nothing to compile.
+			continue
+		}
+		body := string(src)
+		body = body[strings.Index(body, "\nvar ")+1:]
+		b.WriteString(body)
+		b.WriteString("\n")
+		seeds = append(seeds, seed)
+	}
+
+	b.WriteString("var compiledPrograms = map[int64]intcode.Compiled{\n")
+	for _, seed := range seeds {
+		fmt.Fprintf(&b, "%d: compiled%d,\n", seed, seed)
+	}
+	b.WriteString("}\n\nvar compiledImages = map[int64][]int{\n")
+	for _, seed := range seeds {
+		fmt.Fprintf(&b, "%d: compiled%dProgram,\n", seed, seed)
+	}
+	b.WriteString("}\n")
+
+	src, err := format.Source(b.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(path, src, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
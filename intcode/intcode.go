@@ -0,0 +1,374 @@
+// Package intcode implements the Intcode computer shared by the daily
+// puzzles: a Machine with the full opcode set and all three parameter modes.
+package intcode
+
+import (
+	"context"
+	"fmt"
+	"io"
+)
+
+const (
+	ADD           = 1
+	MULTIPLY      = 2
+	STORE         = 3
+	LOAD          = 4
+	JUMP_IF_TRUE  = 5
+	JUMP_IF_FALSE = 6
+	LESS_THAN     = 7
+	EQUALS        = 8
+	RELATIVE_BASE = 9
+	HALT          = 99
+)
+
+const (
+	POSITION  = 0
+	IMMEDIATE = 1
+	RELATIVE  = 2
+)
+
+// Machine is a single Intcode computer. Input is read from and output is
+// written to the source and sink it is connected to, usually the channels
+// handed to Run. Values queued with Feed are read before the input source,
+// and without an output sink outputs are kept until collected with
+// TakeOutput.
+type Machine struct {
+	Memory       *Memory
+	IP           int
+	RelativeBase int
+	Halted       bool
+
+	// OnInput, if set, is called from the machine's goroutine before each
+	// input instruction. The machine is in a consistent state at that point,
+	// so this is where snapshots of a running machine can be taken.
+	OnInput func(m *Machine)
+
+	// Trace, if set, records every executed instruction.
+	Trace *TraceWriter
+
+	// Profile, if set, counts every executed instruction.
+	Profile *Profile
+
+	// Steps counts executed instructions. When MaxSteps is positive the
+	// machine faults with ErrStepLimit instead of running past it.
+	Steps    int64
+	MaxSteps int64
+
+	// Checked makes ADD and MULTIPLY fault with ErrOverflow when the result
+	// does not fit in an int, instead of wrapping around.
+	Checked bool
+
+	// Dialect is the instruction set the machine runs, Full if nil. Set it
+	// before the machine runs.
+	Dialect *Dialect
+
+	ctx        context.Context
+	in         InputSource
+	out        OutputSink
+	pendingIn  []int
+	pendingOut []int
+	err        error
+}
+
+// NewMachine returns a machine loaded with a private copy of program.
+func NewMachine(program []int) *Machine {
+	m := Machine{
+		Memory: NewMemory(program),
+		ctx:    context.Background(),
+	}
+	return &m
+}
+
+// RunProgram runs a fresh copy of program until it halts or faults, then
+// closes output.
+func RunProgram(program []int, input chan int, output chan int) error {
+	return NewMachine(program).Run(input, output)
+}
+
+// RunProgramContext is RunProgram stopping when ctx is done or, if maxSteps
+// is positive, after maxSteps instructions.
+func RunProgramContext(ctx context.Context, program []int, maxSteps int64, input chan int, output chan int) error {
+	m := NewMachine(program)
+	m.MaxSteps = maxSteps
+	return m.RunContext(ctx, input, output)
+}
+
+// Run executes instructions until the machine halts or faults, then closes
+// output. Either channel may be nil for programs that do no I/O. The fault,
+// if any, is returned and is also available from Err once output is closed.
+func (m *Machine) Run(input chan int, output chan int) error {
+	return m.RunContext(context.Background(), input, output)
+}
+
+// RunContext is Run that also stops when ctx is done, even while blocked on
+// a channel. The error then wraps ctx.Err().
+func (m *Machine) RunContext(ctx context.Context, input chan int, output chan int) error {
+	m.Attach(input, output)
+	return m.run(ctx)
+}
+
+// RunIO is RunContext for any input source and output sink. Either may be
+// nil. The sink is closed at the end of the run if it is an io.Closer.
+func (m *Machine) RunIO(ctx context.Context, in InputSource, out OutputSink) error {
+	m.Connect(in, out)
+	return m.run(ctx)
+}
+
+func (m *Machine) run(ctx context.Context) error {
+	m.ctx = ctx
+	defer func() {
+		m.ctx = context.Background()
+	}()
+
+	for !m.Halted {
+		if m.Steps%cancelCheck == 0 {
+			if err := ctx.Err(); err != nil {
+				return m.stop(m.fail(err))
+			}
+		}
+		if err := m.Step(); err != nil {
+			return m.stop(err)
+		}
+	}
+	return m.stop(nil)
+}
+
+// cancelCheck is how many instructions run between checks of the context
+// while the machine is busy computing.
+const cancelCheck = 1024
+
+// Attach connects the machine to the channels used by Step, for callers that
+// drive it one instruction at a time.
+func (m *Machine) Attach(input chan int, output chan int) {
+	var in InputSource
+	var out OutputSink
+	if input != nil {
+		in = ChanInput(input)
+	}
+	if output != nil {
+		out = ChanOutput(output)
+	}
+	m.Connect(in, out)
+}
+
+// Connect is Attach for any input source and output sink.
+func (m *Machine) Connect(in InputSource, out OutputSink) {
+	m.in = in
+	m.out = out
+}
+
+// stop records the outcome of a run and closes the output sink.
+func (m *Machine) stop(err error) error {
+	m.err = err
+	if m.Trace != nil {
+		if ferr := m.Trace.Flush(); ferr != nil && err == nil {
+			m.err = ferr
+		}
+	}
+	if c, ok := m.out.(io.Closer); ok {
+		c.Close()
+	}
+	return m.err
+}
+
+// Err returns the fault that stopped Run, or nil.
+func (m *Machine) Err() error {
+	return m.err
+}
+
+// Feed queues values to be read by the next input instructions.
+func (m *Machine) Feed(values ...int) {
+	m.pendingIn = append(m.pendingIn, values...)
+}
+
+// TakeOutput returns and clears the outputs collected while no output
+// sink was connected.
+func (m *Machine) TakeOutput() []int {
+	out := m.pendingOut
+	m.pendingOut = nil
+	return out
+}
+
+// NextOutput steps the machine until it produces an output and returns it.
+// It needs all input to be queued with Feed and no output sink connected.
+func (m *Machine) NextOutput() (int, error) {
+	for len(m.pendingOut) == 0 {
+		if m.Halted {
+			return 0, ErrHalted
+		}
+		if err := m.Step(); err != nil {
+			return 0, err
+		}
+	}
+	v := m.pendingOut[0]
+	m.pendingOut = m.pendingOut[1:]
+	return v, nil
+}
+
+// Resume runs the machine without goroutines until it halts, needs input
+// that is not available, or has n outputs to return (n <= 0 for no limit).
+// It needs no output sink connected. When waiting for input it returns the
+// outputs so far and an error wrapping ErrNoInput; Feed the machine and
+// call Resume again to continue.
+func (m *Machine) Resume(n int) ([]int, error) {
+	for !m.Halted && (n <= 0 || len(m.pendingOut) < n) {
+		if err := m.Step(); err != nil {
+			return m.TakeOutput(), err
+		}
+	}
+	if n <= 0 || len(m.pendingOut) <= n {
+		return m.TakeOutput(), nil
+	}
+	out := m.pendingOut[:n:n]
+	m.pendingOut = m.pendingOut[n:]
+	return out, nil
+}
+
+// Input reads a value the way an input instruction does: first from the
+// values queued with Feed, then from the input source. Step uses it, as does
+// code running the machine without Step, such as compiled programs.
+func (m *Machine) Input() (int, error) {
+	if m.OnInput != nil {
+		m.OnInput(m)
+	}
+	if len(m.pendingIn) > 0 {
+		value := m.pendingIn[0]
+		m.pendingIn = m.pendingIn[1:]
+		return value, nil
+	}
+	if m.in == nil {
+		return 0, ErrNoInput
+	}
+	return m.in.Input(m.ctx)
+}
+
+// Output writes a value the way an output instruction does: to the output
+// sink, or to the outputs kept for TakeOutput.
+func (m *Machine) Output(value int) error {
+	if m.out == nil {
+		m.pendingOut = append(m.pendingOut, value)
+		return nil
+	}
+	return m.out.Output(m.ctx, value)
+}
+
+// Read returns the value stored at addr.
+func (m *Machine) Read(addr int) int {
+	return m.Memory.Read(addr)
+}
+
+// Write stores value at addr.
+func (m *Machine) Write(addr int, value int) error {
+	return m.Memory.Store(addr, value)
+}
+
+// Step executes the single instruction at IP. On a fault IP is left on the
+// offending instruction and a *MachineError is returned.
+func (m *Machine) Step() error {
+	if m.Halted {
+		return nil
+	}
+
+	i := m.IP
+	if i < 0 || i >= m.Memory.Limit {
+		return m.fail(ErrIPOutOfBounds)
+	}
+	if m.MaxSteps > 0 && m.Steps >= m.MaxSteps {
+		return m.fail(fmt.Errorf("%w: %v", ErrStepLimit, m.MaxSteps))
+	}
+
+	d := m.Dialect
+	if d == nil {
+		d = Full
+	}
+	inst := m.Memory.fetch(i, d)
+	code, opcode, n := inst.code, inst.opcode, inst.arity
+	if inst.op == nil {
+		return m.fail(&DialectError{Dialect: d.Name, Opcode: opcode, Mode: -1})
+	}
+	if i+n >= m.Memory.Limit {
+		return m.fail(ErrIPOutOfBounds)
+	}
+
+	var param [3]int
+	for k := 0; k < n; k++ {
+		if k == inst.badParam {
+			return m.fail(&DialectError{Dialect: d.Name, Opcode: opcode, Mode: inst.modes[k]})
+		}
+		var err error
+		if k == inst.target {
+			param[k], err = m.loadPos(inst.params[k], inst.modes[k])
+		} else {
+			param[k], err = m.loadParam(inst.params[k], inst.modes[k])
+		}
+		if err != nil {
+			return m.fail(err)
+		}
+	}
+
+	i, err := inst.op.Exec(m, i, param)
+	if err != nil {
+		return m.fail(err)
+	}
+	if m.Trace != nil {
+		m.Trace.record(m, m.IP, code, &param, n)
+	}
+	if m.Profile != nil {
+		value := param[0]
+		if opcode == STORE {
+			value = m.Memory.Read(param[0])
+		}
+		m.Profile.record(m.IP, opcode, value)
+	}
+	m.IP = i
+	m.Steps++
+	return nil
+}
+
+// arity returns the number of parameters taken by opcode in the full
+// instruction set.
+func arity(opcode int) (int, bool) {
+	op := Full.Op(opcode)
+	if op == nil {
+		return 0, false
+	}
+	return op.Arity, true
+}
+
+// isTarget reports whether parameter k of opcode is an address written to.
+func isTarget(opcode int, k int) bool {
+	op := Full.Op(opcode)
+	return op != nil && op.Writes && k == op.Arity-1
+}
+
+func (m *Machine) loadParam(data int, mode int) (int, error) {
+	switch mode {
+	case IMMEDIATE:
+		return data, nil
+	case POSITION:
+		return m.load(data)
+	case RELATIVE:
+		return m.load(m.RelativeBase + data)
+	}
+	return 0, fmt.Errorf("%w: %v", ErrInvalidMode, mode)
+}
+
+func (m *Machine) loadPos(pos int, mode int) (int, error) {
+	switch mode {
+	case POSITION:
+		return pos, nil
+	case RELATIVE:
+		return m.RelativeBase + pos, nil
+	case IMMEDIATE:
+		return 0, ErrImmediateWrite
+	}
+	return 0, fmt.Errorf("%w: %v", ErrInvalidMode, mode)
+}
+
+func (m *Machine) load(addr int) (int, error) {
+	return m.Memory.Load(addr)
+}
+
+func (m *Machine) store(addr int, value int) error {
+	return m.Memory.Store(addr, value)
+}
@@ -0,0 +1,32 @@
+package intcode
+
+import (
+	"io/ioutil"
+	"testing"
+)
+
+// fibProgram is the recursive Fibonacci workload of the bench command, a
+// relative mode heavy program in the style of BOOST.
+func fibProgram(t testing.TB) []int {
+	t.Helper()
+	src, err := ioutil.ReadFile("../bench/fib.asm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return assemble(t, string(src))
+}
+
+func TestStepAllocs(t *testing.T) {
+	m := NewMachine(fibProgram(t))
+	m.Feed(30)
+	allocs := testing.AllocsPerRun(100, func() {
+		for k := 0; k < 1000; k++ {
+			if err := m.Step(); err != nil {
+				t.Fatal(err)
+			}
+		}
+	})
+	if allocs != 0 {
+		t.Fatalf("%v allocations per 1000 steps, want none", allocs)
+	}
+}
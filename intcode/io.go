@@ -0,0 +1,140 @@
+package intcode
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+)
+
+// InputSource supplies the values read by input instructions. Input may
+// block until a value is available or ctx is done. A source with nothing to
+// offer right now returns ErrNoInput, which pauses the machine on the input
+// instruction so that it can be resumed later.
+type InputSource interface {
+	Input(ctx context.Context) (int, error)
+}
+
+// OutputSink receives the values written by output instructions.
+type OutputSink interface {
+	Output(ctx context.Context, value int) error
+}
+
+// ChanInput reads input from a channel. A closed channel is ErrInputClosed.
+type ChanInput chan int
+
+func (c ChanInput) Input(ctx context.Context) (int, error) {
+	select {
+	case value, ok := <-c:
+		if !ok {
+			return 0, ErrInputClosed
+		}
+		return value, nil
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	}
+}
+
+// ChanOutput sends output to a channel, which is closed when the run ends.
+type ChanOutput chan int
+
+func (c ChanOutput) Output(ctx context.Context, value int) error {
+	select {
+	case c <- value:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (c ChanOutput) Close() error {
+	close(c)
+	return nil
+}
+
+// SliceInput reads input from Values, returning ErrNoInput once they are
+// used up. More values may be appended while the machine is paused.
+type SliceInput struct {
+	Values []int
+}
+
+// NewSliceInput returns a source reading values in order.
+func NewSliceInput(values ...int) *SliceInput {
+	return &SliceInput{Values: values}
+}
+
+func (s *SliceInput) Input(ctx context.Context) (int, error) {
+	if len(s.Values) == 0 {
+		return 0, ErrNoInput
+	}
+	value := s.Values[0]
+	s.Values = s.Values[1:]
+	return value, nil
+}
+
+// SliceOutput collects output in Values.
+type SliceOutput struct {
+	Values []int
+}
+
+func (s *SliceOutput) Output(ctx context.Context, value int) error {
+	s.Values = append(s.Values, value)
+	return nil
+}
+
+// InputFunc adapts a function to an InputSource.
+type InputFunc func() (int, error)
+
+func (f InputFunc) Input(ctx context.Context) (int, error) {
+	return f()
+}
+
+// OutputFunc adapts a function to an OutputSink.
+type OutputFunc func(value int) error
+
+func (f OutputFunc) Output(ctx context.Context, value int) error {
+	return f(value)
+}
+
+// ASCIIInput reads input as the bytes of a text stream. The end of the
+// stream is ErrInputClosed.
+type ASCIIInput struct {
+	r *bufio.Reader
+}
+
+// NewASCIIInput returns a source reading the bytes of r.
+func NewASCIIInput(r io.Reader) *ASCIIInput {
+	return &ASCIIInput{r: bufio.NewReader(r)}
+}
+
+func (a *ASCIIInput) Input(ctx context.Context) (int, error) {
+	c, err := a.r.ReadByte()
+	if err == io.EOF {
+		return 0, ErrInputClosed
+	}
+	if err != nil {
+		return 0, err
+	}
+	return int(c), nil
+}
+
+// ASCIIOutput writes output as text. Values outside the byte range are
+// written as decimal numbers on a line of their own.
+type ASCIIOutput struct {
+	w io.Writer
+}
+
+// NewASCIIOutput returns a sink writing text to w.
+func NewASCIIOutput(w io.Writer) *ASCIIOutput {
+	return &ASCIIOutput{w: w}
+}
+
+func (a *ASCIIOutput) Output(ctx context.Context, value int) error {
+	var err error
+	if value >= 0 && value < 256 {
+		_, err = a.w.Write([]byte{byte(value)})
+	} else {
+		_, err = fmt.Fprintf(a.w, "%d\n", value)
+	}
+	return err
+}
@@ -0,0 +1,195 @@
+package intcode
+
+import (
+	"fmt"
+)
+
+const (
+	pageBits = 10
+	pageSize = 1 << pageBits
+
+	// Addresses below denseLimit live in pages indexed by a slice, anything
+	// above is kept in a sparse map.
+	denseLimit = 1 << 20
+)
+
+// DefaultMemoryLimit is the number of addressable cells a new Memory allows.
+const DefaultMemoryLimit = 1 << 30
+
+type page [pageSize]int
+
+// Memory is the address space of a Machine. It grows on demand: the program
+// image and anything near it is stored in fixed size pages, far addresses in
+// a sparse map. Reads of cells never written return 0.
+//
+// Pages are shared copy-on-write between a memory and its clones; owned
+// marks the pages this memory may modify in place. Executed instructions are
+// kept decoded in code, shared the same way, until written to.
+type Memory struct {
+	// Limit is one past the highest address that may be accessed.
+	Limit int
+
+	pages     []*page
+	owned     []bool
+	far       map[int]int
+	code      []*codePage
+	codeOwned []bool
+	// dialect is the dialect the cache was decoded for.
+	dialect *Dialect
+	// scratch holds the instruction decoded last when it is not cached.
+	scratch cached
+	// uncached turns the cache off, to measure what it saves.
+	uncached bool
+}
+
+// NewMemory returns a memory holding a copy of program at address 0.
+func NewMemory(program []int) *Memory {
+	mem := Memory{
+		Limit: DefaultMemoryLimit,
+	}
+	for addr, v := range program {
+		mem.set(addr, v)
+	}
+	return &mem
+}
+
+// Read returns the value at addr, or 0 for addresses that are out of range.
+func (mem *Memory) Read(addr int) int {
+	v, _ := mem.Load(addr)
+	return v
+}
+
+// Load returns the value at addr.
+func (mem *Memory) Load(addr int) (int, error) {
+	if err := mem.check(addr); err != nil {
+		return 0, err
+	}
+	if addr < denseLimit {
+		n := addr >> pageBits
+		if n >= len(mem.pages) || mem.pages[n] == nil {
+			return 0, nil
+		}
+		return mem.pages[n][addr&(pageSize-1)], nil
+	}
+	return mem.far[addr], nil
+}
+
+// Store writes value at addr, growing the memory as needed.
+func (mem *Memory) Store(addr int, value int) error {
+	if err := mem.check(addr); err != nil {
+		return err
+	}
+	mem.set(addr, value)
+	return nil
+}
+
+// Len returns one past the highest address that has backing storage.
+func (mem *Memory) Len() int {
+	n := len(mem.pages) << pageBits
+	for addr := range mem.far {
+		if addr >= n {
+			n = addr + 1
+		}
+	}
+	return n
+}
+
+// Image returns a copy of the cells below the sparse region, without
+// trailing zeros.
+func (mem *Memory) Image() []int {
+	n := len(mem.pages) << pageBits
+	for n > 0 && mem.Read(n-1) == 0 {
+		n--
+	}
+	image := make([]int, n)
+	for addr := range image {
+		image[addr] = mem.Read(addr)
+	}
+	return image
+}
+
+func (mem *Memory) check(addr int) error {
+	if addr < 0 {
+		return fmt.Errorf("%w: %v", ErrNegativeAddress, addr)
+	}
+	if addr >= mem.Limit {
+		return fmt.Errorf("%w: %v (limit %v)", ErrMemoryLimit, addr, mem.Limit)
+	}
+	return nil
+}
+
+func (mem *Memory) set(addr int, value int) {
+	if mem.code != nil {
+		mem.invalidate(addr)
+	}
+	if addr >= denseLimit {
+		if mem.far == nil {
+			mem.far = make(map[int]int)
+		}
+		mem.far[addr] = value
+		return
+	}
+
+	n := addr >> pageBits
+	if n >= len(mem.pages) {
+		if value == 0 {
+			return
+		}
+		mem.grow(n + 1)
+	}
+	if mem.pages[n] == nil {
+		if value == 0 {
+			return
+		}
+		mem.pages[n] = new(page)
+		mem.owned[n] = true
+	} else if !mem.owned[n] {
+		p := *mem.pages[n]
+		mem.pages[n] = &p
+		mem.owned[n] = true
+	}
+	mem.pages[n][addr&(pageSize-1)] = value
+}
+
+func (mem *Memory) grow(n int) {
+	if n <= cap(mem.pages) {
+		mem.pages = mem.pages[:n]
+		mem.owned = mem.owned[:n]
+		return
+	}
+	pages := make([]*page, n, 2*n)
+	copy(pages, mem.pages)
+	mem.pages = pages
+	owned := make([]bool, n, 2*n)
+	copy(owned, mem.owned)
+	mem.owned = owned
+}
+
+// Clone returns a copy of the memory. Pages and their decoded instructions
+// are shared until either side writes to them, so cloning is cheap even for
+// large images and the clone does not decode its code again. Clone must not
+// run concurrently with a machine running on mem.
+func (mem *Memory) Clone() *Memory {
+	c := Memory{
+		Limit:     mem.Limit,
+		pages:     make([]*page, len(mem.pages)),
+		owned:     make([]bool, len(mem.pages)),
+		code:      append([]*codePage(nil), mem.code...),
+		codeOwned: make([]bool, len(mem.code)),
+		dialect:   mem.dialect,
+	}
+	copy(c.pages, mem.pages)
+	for i := range mem.owned {
+		mem.owned[i] = false
+	}
+	for i := range mem.codeOwned {
+		mem.codeOwned[i] = false
+	}
+	if mem.far != nil {
+		c.far = make(map[int]int, len(mem.far))
+		for addr, v := range mem.far {
+			c.far[addr] = v
+		}
+	}
+	return &c
+}
@@ -0,0 +1,320 @@
+package intcode
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+var (
+	ErrBadAddress = errors.New("no machine at address")
+	// ErrStop is returned by a network hook to end the run without error.
+	ErrStop = errors.New("stop network")
+	// ErrIdle is returned when the network goes idle and there is no OnIdle
+	// hook to wake it up.
+	ErrIdle = errors.New("network idle")
+)
+
+// Packet is an (X, Y) message for the machine at address Dest. Machines send
+// packets as three outputs: the address, X and Y.
+type Packet struct {
+	Dest int
+	X    int
+	Y    int
+}
+
+// Network runs machines that talk to each other with packets. Every machine
+// boots with its address as first input and then reads Empty whenever it has
+// no packet waiting.
+type Network struct {
+	Machines []*Machine
+	// Empty is read by a machine whose queue is empty.
+	Empty int
+
+	// Monitor is an address outside the network. Packets sent to it are
+	// handed to OnMonitor instead of a machine.
+	Monitor   int
+	OnMonitor func(p Packet) error
+	// OnIdle is called when every queue is empty and every machine is
+	// waiting for input. It may wake the network up with Send. Without it
+	// an idle network can never go on, and the run ends with ErrIdle.
+	OnIdle func() error
+
+	queues [][]Packet
+	nodes  []*node
+}
+
+// NewNetwork boots size copies of program at addresses 0..size-1.
+func NewNetwork(program []int, size int) *Network {
+	n := Network{
+		Empty:   -1,
+		Monitor: -1,
+		queues:  make([][]Packet, size),
+	}
+	for addr := 0; addr < size; addr++ {
+		m := NewMachine(program)
+		m.Feed(addr)
+		n.Machines = append(n.Machines, m)
+	}
+	return &n
+}
+
+// Send delivers p to its destination. It never blocks: packets wait in the
+// destination's queue until the machine reads them. While the network runs
+// it is only safe to call from the hooks.
+func (n *Network) Send(p Packet) error {
+	if p.Dest == n.Monitor && n.OnMonitor != nil {
+		return n.OnMonitor(p)
+	}
+	if p.Dest < 0 || p.Dest >= len(n.Machines) {
+		return fmt.Errorf("%w: %v", ErrBadAddress, p.Dest)
+	}
+	if n.nodes != nil {
+		n.nodes[p.Dest].push(p)
+	} else {
+		n.queues[p.Dest] = append(n.queues[p.Dest], p)
+	}
+	return nil
+}
+
+// Run schedules the machines round-robin in a single goroutine, so every run
+// of the same network is identical. On its turn a machine gets the next
+// packet of its queue, or Empty, and runs until it waits for more input.
+// Run returns when all machines have halted, a machine faults or a hook
+// returns an error; ErrStop ends the run with nil.
+func (n *Network) Run() error {
+	partial := make([][]int, len(n.Machines))
+
+	for {
+		idle, halted := true, 0
+		for addr, m := range n.Machines {
+			if m.Halted {
+				halted++
+				continue
+			}
+			if q := n.queues[addr]; len(q) > 0 {
+				m.Feed(q[0].X, q[0].Y)
+				n.queues[addr] = q[1:]
+				idle = false
+			} else {
+				m.Feed(n.Empty)
+			}
+
+			out, err := m.Resume(0)
+			if err != nil && !errors.Is(err, ErrNoInput) {
+				return err
+			}
+			if len(out) > 0 {
+				idle = false
+			}
+			partial[addr] = append(partial[addr], out...)
+			for len(partial[addr]) >= 3 {
+				p := Packet{Dest: partial[addr][0], X: partial[addr][1], Y: partial[addr][2]}
+				partial[addr] = partial[addr][3:]
+				if err := n.Send(p); err != nil {
+					return stopped(err)
+				}
+			}
+		}
+		if halted == len(n.Machines) {
+			return nil
+		}
+
+		if idle && n.empty() {
+			if n.OnIdle == nil {
+				return ErrIdle
+			}
+			if err := n.OnIdle(); err != nil {
+				return stopped(err)
+			}
+		}
+	}
+}
+
+func (n *Network) empty() bool {
+	for _, q := range n.queues {
+		if len(q) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func stopped(err error) error {
+	if err == ErrStop {
+		return nil
+	}
+	return err
+}
+
+// RunConcurrent runs every machine in its own goroutine, connected to a
+// router by channels. The order in which packets arrive is up to the
+// scheduler. It stops under the same conditions as Run, or when ctx is done.
+func (n *Network) RunConcurrent(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	packets := make(chan Packet)
+	poke := make(chan struct{}, 1)
+	nodes := make([]*node, len(n.Machines))
+	n.nodes = nodes
+	defer func() {
+		n.nodes = nil
+	}()
+
+	var wg sync.WaitGroup
+	errs := make(chan error, len(n.Machines))
+	for addr, m := range n.Machines {
+		nodes[addr] = &node{
+			empty:   n.Empty,
+			packets: packets,
+			poke:    poke,
+		}
+		if m.Halted {
+			nodes[addr].halted = 1
+			continue
+		}
+		wg.Add(1)
+		go func(m *Machine, nd *node) {
+			defer wg.Done()
+			err := m.RunIO(ctx, nd, nd)
+			atomic.StoreInt32(&nd.halted, 1)
+			if err != nil && ctx.Err() == nil {
+				errs <- err
+			}
+			select {
+			case poke <- struct{}{}:
+			default:
+			}
+		}(m, nodes[addr])
+	}
+
+	err := n.route(ctx, nodes, packets, poke, errs)
+	cancel()
+	wg.Wait()
+	return stopped(err)
+}
+
+func (n *Network) route(ctx context.Context, nodes []*node, packets chan Packet, poke chan struct{}, errs chan error) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case err := <-errs:
+			return err
+		case p := <-packets:
+			if err := n.Send(p); err != nil {
+				return err
+			}
+		case <-poke:
+			halted, idle := 0, true
+			for _, nd := range nodes {
+				if atomic.LoadInt32(&nd.halted) == 1 {
+					halted++
+				} else if atomic.LoadInt32(&nd.emptyReads) < 2 || nd.queued() > 0 {
+					idle = false
+				}
+			}
+			if halted == len(nodes) {
+				return nil
+			}
+			if idle {
+				if n.OnIdle == nil {
+					return ErrIdle
+				}
+				for _, nd := range nodes {
+					atomic.StoreInt32(&nd.emptyReads, 0)
+				}
+				if err := n.OnIdle(); err != nil {
+					return err
+				}
+			}
+		}
+	}
+}
+
+// node connects a machine of a concurrent network to the router. A machine
+// counts as waiting once it has read Empty twice in a row without sending.
+type node struct {
+	empty   int
+	packets chan Packet
+	poke    chan struct{}
+
+	// inbox holds the packets sent to the machine and not yet read. It has
+	// no bound, so the router never waits for a machine to read.
+	mu    sync.Mutex
+	inbox []Packet
+
+	y          []int
+	out        []int
+	emptyReads int32
+	halted     int32
+}
+
+func (nd *node) push(p Packet) {
+	nd.mu.Lock()
+	nd.inbox = append(nd.inbox, p)
+	nd.mu.Unlock()
+}
+
+func (nd *node) pop() (Packet, bool) {
+	nd.mu.Lock()
+	defer nd.mu.Unlock()
+	if len(nd.inbox) == 0 {
+		return Packet{}, false
+	}
+	p := nd.inbox[0]
+	nd.inbox = nd.inbox[1:]
+	return p, true
+}
+
+func (nd *node) queued() int {
+	nd.mu.Lock()
+	defer nd.mu.Unlock()
+	return len(nd.inbox)
+}
+
+func (nd *node) Input(ctx context.Context) (int, error) {
+	if len(nd.y) > 0 {
+		v := nd.y[0]
+		nd.y = nd.y[1:]
+		return v, nil
+	}
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	if p, ok := nd.pop(); ok {
+		atomic.StoreInt32(&nd.emptyReads, 0)
+		nd.y = append(nd.y, p.Y)
+		return p.X, nil
+	}
+
+	if atomic.AddInt32(&nd.emptyReads, 1) >= 2 {
+		select {
+		case nd.poke <- struct{}{}:
+		default:
+		}
+	}
+	runtime.Gosched()
+	return nd.empty, nil
+}
+
+func (nd *node) Output(ctx context.Context, value int) error {
+	atomic.StoreInt32(&nd.emptyReads, 0)
+	nd.out = append(nd.out, value)
+	if len(nd.out) < 3 {
+		return nil
+	}
+	p := Packet{Dest: nd.out[0], X: nd.out[1], Y: nd.out[2]}
+	nd.out = nd.out[:0]
+
+	select {
+	case nd.packets <- p:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,96 @@
+package intcode
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func assemble(t testing.TB, src string) []int {
+	t.Helper()
+	program, err := Assemble(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return program
+}
+
+// listener reads packets forever and never sends any.
+const listener = `
+loop:   IN   [x]
+        JT   #1, #loop
+x:      db 0
+`
+
+func TestNetworkIdle(t *testing.T) {
+	n := NewNetwork(assemble(t, listener), 3)
+	if err := n.Run(); !errors.Is(err, ErrIdle) {
+		t.Fatalf("Run: got %v, want %v", err, ErrIdle)
+	}
+
+	n = NewNetwork(assemble(t, listener), 3)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := n.RunConcurrent(ctx); !errors.Is(err, ErrIdle) {
+		t.Fatalf("RunConcurrent: got %v, want %v", err, ErrIdle)
+	}
+}
+
+func TestNetworkIdleHook(t *testing.T) {
+	n := NewNetwork(assemble(t, listener), 2)
+	calls := 0
+	n.OnIdle = func() error {
+		calls++
+		if calls == 3 {
+			return ErrStop
+		}
+		return n.Send(Packet{Dest: 1, X: calls, Y: calls})
+	}
+	if err := n.Run(); err != nil {
+		t.Fatal(err)
+	}
+	if calls != 3 {
+		t.Fatalf("OnIdle called %d times, want 3", calls)
+	}
+}
+
+// flood has machine 0 send packets to machine 1, which never reads them,
+// and then report to address 255. The other machines spin without reading.
+const flood = `
+        IN   [addr]
+        JF   [addr], #send
+spin:   JT   #1, #spin
+send:   OUT  #1
+        OUT  [i]
+        OUT  #0
+        ADD  [i], #1, [i]
+        LT   [i], #10000, [t]
+        JT   [t], #send
+        OUT  #255
+        OUT  [i]
+        OUT  #0
+        HLT
+addr:   db 0
+i:      db 0
+t:      db 0
+`
+
+func TestNetworkFlood(t *testing.T) {
+	n := NewNetwork(assemble(t, flood), 2)
+	n.Monitor = 255
+	var got Packet
+	n.OnMonitor = func(p Packet) error {
+		got = p
+		return ErrStop
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := n.RunConcurrent(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if got.X != 10000 {
+		t.Fatalf("monitor got %v, want 10000 packets sent", got)
+	}
+}
@@ -0,0 +1,186 @@
+package intcode
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// maxUnknowns is the number of variables a poly can hold.
+const maxUnknowns = 8
+
+// maxTerms bounds the size of a poly; beyond it the solver stops treating
+// the value symbolically.
+const maxTerms = 1 << 10
+
+// monomial holds the exponent of each variable.
+type monomial [maxUnknowns]uint8
+
+// poly is a polynomial over the solver's unknowns with int coefficients.
+// Coefficients wrap around exactly like Machine arithmetic, so evaluating a
+// poly gives the value the machine would compute. Zero terms are omitted.
+type poly map[monomial]int
+
+func constant(c int) poly {
+	if c == 0 {
+		return poly{}
+	}
+	return poly{monomial{}: c}
+}
+
+func variable(j int) poly {
+	var m monomial
+	m[j] = 1
+	return poly{m: 1}
+}
+
+// value returns the poly's value if it does not depend on any variable.
+func (p poly) value() (int, bool) {
+	switch len(p) {
+	case 0:
+		return 0, true
+	case 1:
+		c, ok := p[monomial{}]
+		return c, ok
+	}
+	return 0, false
+}
+
+func (p poly) add(q poly) poly {
+	r := make(poly, len(p)+len(q))
+	for m, c := range p {
+		r[m] = c
+	}
+	for m, c := range q {
+		if r[m] += c; r[m] == 0 {
+			delete(r, m)
+		}
+	}
+	return r
+}
+
+// mul returns p*q, or false if the product has an exponent or a number of
+// terms too large to represent.
+func (p poly) mul(q poly) (poly, bool) {
+	r := make(poly)
+	for m1, c1 := range p {
+		for m2, c2 := range q {
+			var m monomial
+			for j := range m {
+				e := int(m1[j]) + int(m2[j])
+				if e > 255 {
+					return nil, false
+				}
+				m[j] = uint8(e)
+			}
+			if r[m] += c1 * c2; r[m] == 0 {
+				delete(r, m)
+			}
+		}
+	}
+	return r, len(r) <= maxTerms
+}
+
+// deps returns the variables p depends on, in order.
+func (p poly) deps() []int {
+	var used [maxUnknowns]bool
+	for m := range p {
+		for j, e := range m {
+			if e > 0 {
+				used[j] = true
+			}
+		}
+	}
+	var deps []int
+	for j, u := range used {
+		if u {
+			deps = append(deps, j)
+		}
+	}
+	return deps
+}
+
+// degree returns the highest exponent of variable j.
+func (p poly) degree(j int) int {
+	d := 0
+	for m := range p {
+		if int(m[j]) > d {
+			d = int(m[j])
+		}
+	}
+	return d
+}
+
+// substitute returns p with variable j replaced by v.
+func (p poly) substitute(j int, v int) poly {
+	r := make(poly, len(p))
+	for m, c := range p {
+		for e := m[j]; e > 0; e-- {
+			c *= v
+		}
+		m[j] = 0
+		if r[m] += c; r[m] == 0 {
+			delete(r, m)
+		}
+	}
+	return r
+}
+
+// format renders p with names for the variables, highest degree first.
+func (p poly) format(names []string) string {
+	if len(p) == 0 {
+		return "0"
+	}
+	var ms []monomial
+	for m := range p {
+		ms = append(ms, m)
+	}
+	total := func(m monomial) int {
+		t := 0
+		for _, e := range m {
+			t += int(e)
+		}
+		return t
+	}
+	sort.Slice(ms, func(i, j int) bool {
+		if ti, tj := total(ms[i]), total(ms[j]); ti != tj {
+			return ti > tj
+		}
+		for k := range ms[i] {
+			if ms[i][k] != ms[j][k] {
+				return ms[i][k] > ms[j][k]
+			}
+		}
+		return false
+	})
+
+	var b strings.Builder
+	for i, m := range ms {
+		c := p[m]
+		switch {
+		case i > 0 && c < 0:
+			b.WriteString(" - ")
+			c = -c
+		case i > 0:
+			b.WriteString(" + ")
+		}
+		var factors []string
+		for j, e := range m {
+			switch {
+			case e == 1:
+				factors = append(factors, names[j])
+			case e > 1:
+				factors = append(factors, fmt.Sprintf("%s^%d", names[j], e))
+			}
+		}
+		switch {
+		case len(factors) == 0:
+			fmt.Fprint(&b, c)
+		case c == 1:
+			b.WriteString(strings.Join(factors, "*"))
+		default:
+			fmt.Fprintf(&b, "%d*%s", c, strings.Join(factors, "*"))
+		}
+	}
+	return b.String()
+}
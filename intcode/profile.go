@@ -0,0 +1,202 @@
+package intcode
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+)
+
+// IOEvent is an input or output seen by a Profile.
+type IOEvent struct {
+	Output bool
+	Value  int
+	// Cycles is the number of instructions executed since the previous
+	// event, including this one.
+	Cycles int64
+}
+
+// denseCounts is the number of addresses a Profile counts in a slice. Code
+// beyond it, reached by a wild jump into high memory, is counted in a map
+// so that a single instruction there costs no more than one entry.
+const denseCounts = 1 << 16
+
+// Profile counts executed instructions. Attach it to a machine through
+// Machine.Profile; one profile may collect several sequential runs. It is not
+// safe for concurrent use, so machines running at the same time need a
+// profile each.
+type Profile struct {
+	Cycles int64
+	// Counts holds the execution counts of addresses below denseCounts,
+	// Sparse those of the addresses above.
+	Counts  []int64
+	Sparse  map[int]int64
+	Opcodes map[int]int64
+	Events  []IOEvent
+
+	sinceEvent int64
+}
+
+// NewProfile returns an empty profile.
+func NewProfile() *Profile {
+	return &Profile{
+		Sparse:  make(map[int]int64),
+		Opcodes: make(map[int]int64),
+	}
+}
+
+func (p *Profile) record(ip int, opcode int, value int) {
+	switch {
+	case ip >= denseCounts:
+		p.Sparse[ip]++
+	case ip >= len(p.Counts):
+		counts := make([]int64, ip+1, 2*(ip+1))
+		copy(counts, p.Counts)
+		p.Counts = counts
+		fallthrough
+	default:
+		p.Counts[ip]++
+	}
+	p.Opcodes[opcode]++
+	p.Cycles++
+	p.sinceEvent++
+
+	if opcode == STORE || opcode == LOAD {
+		p.Events = append(p.Events, IOEvent{
+			Output: opcode == LOAD,
+			Value:  value,
+			Cycles: p.sinceEvent,
+		})
+		p.sinceEvent = 0
+	}
+}
+
+// Count returns how often the instruction at addr was executed.
+func (p *Profile) Count(addr int) int64 {
+	if addr < 0 || addr >= len(p.Counts) {
+		return p.Sparse[addr]
+	}
+	return p.Counts[addr]
+}
+
+// WriteReport writes a text summary: opcode mix, the top hot spots of program
+// and the cost of input/output events.
+func (p *Profile) WriteReport(w io.Writer, program []int, top int) {
+	fmt.Fprintf(w, "total cycles: %d\n\n", p.Cycles)
+
+	fmt.Fprintln(w, "opcodes:")
+	var opcodes []int
+	for op := range p.Opcodes {
+		opcodes = append(opcodes, op)
+	}
+	sort.Slice(opcodes, func(i, j int) bool {
+		return p.Opcodes[opcodes[i]] > p.Opcodes[opcodes[j]]
+	})
+	for _, op := range opcodes {
+		fmt.Fprintf(w, "  %-4s %12d %6.2f%%\n", Mnemonic(op), p.Opcodes[op], p.percent(p.Opcodes[op]))
+	}
+
+	fmt.Fprintf(w, "\nhot spots:\n")
+	var addrs []int
+	for addr, n := range p.Counts {
+		if n > 0 {
+			addrs = append(addrs, addr)
+		}
+	}
+	for addr := range p.Sparse {
+		addrs = append(addrs, addr)
+	}
+	sort.Slice(addrs, func(i, j int) bool {
+		return p.Count(addrs[i]) > p.Count(addrs[j])
+	})
+	for k, addr := range addrs {
+		if k == top {
+			break
+		}
+		text := fmt.Sprintf("%6d: ?", addr)
+		if inst, err := Decode(program, addr); err == nil {
+			text = Line{Addr: addr, Inst: &inst}.String()
+		}
+		fmt.Fprintf(w, "  %12d %6.2f%%  %s\n", p.Count(addr), p.percent(p.Count(addr)), text)
+	}
+
+	var inputs, outputs int
+	var most []IOEvent
+	for _, e := range p.Events {
+		if e.Output {
+			outputs++
+		} else {
+			inputs++
+		}
+		most = append(most, e)
+	}
+	fmt.Fprintf(w, "\nio events: %d inputs, %d outputs", inputs, outputs)
+	if len(p.Events) > 0 {
+		fmt.Fprintf(w, ", %d cycles per event on average", p.Cycles/int64(len(p.Events)))
+	}
+	fmt.Fprintln(w)
+	sort.SliceStable(most, func(i, j int) bool {
+		return most[i].Cycles > most[j].Cycles
+	})
+	for k, e := range most {
+		if k == top {
+			break
+		}
+		kind := "in "
+		if e.Output {
+			kind = "out"
+		}
+		fmt.Fprintf(w, "  %s %12d  after %d cycles\n", kind, e.Value, e.Cycles)
+	}
+}
+
+// WriteAnnotated writes the disassembly of program with the execution count
+// of every line. Lines never executed are marked with #####.
+func (p *Profile) WriteAnnotated(w io.Writer, program []int) {
+	var covered, total int
+
+	for _, line := range Disassemble(program, nil) {
+		var n int64
+		for k := range line.Words {
+			n += p.Count(line.Addr + k)
+		}
+		if line.Inst != nil {
+			n = p.Count(line.Addr)
+			total++
+			if n > 0 {
+				covered++
+			}
+		}
+
+		count := "#####"
+		if n > 0 {
+			count = fmt.Sprint(n)
+		} else if line.Inst == nil {
+			count = "-"
+		}
+		fmt.Fprintf(w, "%12s | %s\n", count, line)
+	}
+	if total > 0 {
+		fmt.Fprintf(w, "\ncoverage: %d of %d instructions executed (%.1f%%)\n",
+			covered, total, 100*float64(covered)/float64(total))
+	}
+}
+
+func (p *Profile) percent(n int64) float64 {
+	if p.Cycles == 0 {
+		return 0
+	}
+	return 100 * float64(n) / float64(p.Cycles)
+}
+
+// Save writes the report and the annotated disassembly of program to path.
+func (p *Profile) Save(path string, program []int) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	p.WriteReport(f, program, 20)
+	fmt.Fprintln(f)
+	p.WriteAnnotated(f, program)
+	return f.Close()
+}
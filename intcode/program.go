@@ -0,0 +1,34 @@
+package intcode
+
+import (
+	"io/ioutil"
+	"strconv"
+	"strings"
+)
+
+// BuildList reads a comma-separated Intcode program from dataFile.
+func BuildList(dataFile string) ([]int, error) {
+	data, err := ioutil.ReadFile(dataFile)
+	if err != nil {
+		return nil, err
+	}
+	return ParseProgram(string(data))
+}
+
+// ParseProgram parses comma-separated integers, ignoring surrounding
+// whitespace and line breaks.
+func ParseProgram(text string) ([]int, error) {
+	var program []int
+
+	lists := strings.FieldsFunc(text, func(r rune) bool {
+		return r == ',' || r == '\n' || r == '\r' || r == ' ' || r == '\t'
+	})
+	for _, l := range lists {
+		num, err := strconv.ParseInt(l, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		program = append(program, int(num))
+	}
+	return program, nil
+}
@@ -0,0 +1,163 @@
+package intcode_test
+
+// reference is a deliberately plain Intcode interpreter, written from the
+// puzzle text and sharing no code with the intcode package. Like Machine.Step
+// it takes the modes from the three digits above the opcode and ignores
+// those past the opcode's parameters and any digits above them.
+type reference struct {
+	mem    map[int]int
+	ip     int
+	rb     int
+	input  []int
+	output []int
+	steps  int64
+}
+
+func runReference(program []int, input []int, maxSteps int64) outcome {
+	r := reference{
+		mem:   make(map[int]int),
+		input: input,
+	}
+	for addr, v := range program {
+		r.mem[addr] = v
+	}
+
+	kind := r.run(maxSteps)
+	o := outcome{
+		Kind:    kind,
+		IP:      r.ip,
+		Outputs: r.output,
+	}
+	top := -1
+	for addr, v := range r.mem {
+		if v != 0 && addr > top && addr < imageLimit {
+			top = addr
+		}
+	}
+	o.Image = make([]int, top+1)
+	for addr := range o.Image {
+		o.Image[addr] = r.mem[addr]
+	}
+	return o
+}
+
+func (r *reference) run(maxSteps int64) string {
+	for {
+		if r.steps >= maxSteps {
+			return stepLimit
+		}
+		code := r.mem[r.ip]
+		op := code % 100
+		if code < 0 {
+			return badOpcode
+		}
+		n := map[int]int{1: 3, 2: 3, 3: 1, 4: 1, 5: 2, 6: 2, 7: 3, 8: 3, 9: 1, 99: 0}
+		size, ok := n[op]
+		if !ok {
+			return badOpcode
+		}
+
+		target := -1
+		switch op {
+		case 1, 2, 7, 8:
+			target = 2
+		case 3:
+			target = 0
+		}
+
+		var addr [3]int
+		var mode [3]int
+		for k := 0; k < size; k++ {
+			mode[k] = code / []int{100, 1000, 10000}[k] % 10
+			raw := r.mem[r.ip+1+k]
+			switch mode[k] {
+			case 0:
+				addr[k] = raw
+			case 1:
+				if k == target {
+					return immediateWrite
+				}
+				addr[k] = r.ip + 1 + k
+			case 2:
+				addr[k] = r.rb + raw
+			default:
+				return badMode
+			}
+			if k == target {
+				continue
+			}
+			if fault := check(addr[k]); fault != "" {
+				return fault
+			}
+		}
+		val := func(k int) int {
+			return r.mem[addr[k]]
+		}
+		// The written address is only checked when the value is known, so
+		// an input is consumed before a bad target faults.
+		var fault string
+		set := func(k int, v int) {
+			if fault = check(addr[k]); fault == "" {
+				r.mem[addr[k]] = v
+			}
+		}
+
+		next := r.ip + size + 1
+		switch op {
+		case 1:
+			set(2, val(0)+val(1))
+		case 2:
+			set(2, val(0)*val(1))
+		case 3:
+			if len(r.input) == 0 {
+				return noInput
+			}
+			set(0, r.input[0])
+			r.input = r.input[1:]
+		case 4:
+			r.output = append(r.output, val(0))
+		case 5:
+			if val(0) != 0 {
+				next = val(1)
+			}
+		case 6:
+			if val(0) == 0 {
+				next = val(1)
+			}
+		case 7:
+			if val(0) < val(1) {
+				set(2, 1)
+			} else {
+				set(2, 0)
+			}
+		case 8:
+			if val(0) == val(1) {
+				set(2, 1)
+			} else {
+				set(2, 0)
+			}
+		case 9:
+			r.rb += val(0)
+		case 99:
+			return halted
+		}
+		if fault != "" {
+			return fault
+		}
+		r.ip = next
+		r.steps++
+		if r.ip < 0 || r.ip >= memoryLimit {
+			return badIP
+		}
+	}
+}
+
+func check(addr int) string {
+	if addr < 0 {
+		return badAddress
+	}
+	if addr >= memoryLimit {
+		return overLimit
+	}
+	return ""
+}
@@ -0,0 +1,192 @@
+package intcode
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+)
+
+// Snapshot is the complete state of a Machine at one point of its run. The
+// attached channels are not part of it.
+type Snapshot struct {
+	Memory       *Memory
+	IP           int
+	RelativeBase int
+	Halted       bool
+	Input        []int
+	Output       []int
+	// Dialect is the dialect the machine runs, nil for Full.
+	Dialect *Dialect
+}
+
+// Snapshot captures the state of the machine. It must be called from the
+// goroutine running the machine: taking a snapshot marks the memory pages
+// copy-on-write, so calling it from elsewhere races with the machine even
+// while it is blocked on input. To checkpoint a running machine, take the
+// snapshot from OnInput.
+func (m *Machine) Snapshot() *Snapshot {
+	return &Snapshot{
+		Memory:       m.Memory.Clone(),
+		IP:           m.IP,
+		RelativeBase: m.RelativeBase,
+		Halted:       m.Halted,
+		Input:        append([]int(nil), m.pendingIn...),
+		Output:       append([]int(nil), m.pendingOut...),
+		Dialect:      m.Dialect,
+	}
+}
+
+// Restore puts the machine back into the state captured by s. The snapshot
+// stays valid and can be restored again.
+func (m *Machine) Restore(s *Snapshot) {
+	m.Memory = s.Memory.Clone()
+	m.IP = s.IP
+	m.RelativeBase = s.RelativeBase
+	m.Halted = s.Halted
+	m.pendingIn = append([]int(nil), s.Input...)
+	m.pendingOut = append([]int(nil), s.Output...)
+	m.Dialect = s.Dialect
+	m.err = nil
+}
+
+// Clone returns an independent copy of the machine sharing memory pages
+// copy-on-write. The clone keeps the step count and limit and the arithmetic
+// of m. It has no trace, profile, input or output connected: those record a
+// single run, so a caller wanting the clone's run recorded attaches its own.
+func (m *Machine) Clone() *Machine {
+	c := Machine{
+		Memory:       m.Memory.Clone(),
+		IP:           m.IP,
+		RelativeBase: m.RelativeBase,
+		Halted:       m.Halted,
+		Steps:        m.Steps,
+		MaxSteps:     m.MaxSteps,
+		Checked:      m.Checked,
+		Dialect:      m.Dialect,
+		pendingIn:    append([]int(nil), m.pendingIn...),
+		pendingOut:   append([]int(nil), m.pendingOut...),
+		ctx:          context.Background(),
+	}
+	return &c
+}
+
+// SnapshotVersion is the version of the snapshot file format.
+const SnapshotVersion = 1
+
+// snapshotFile is the on-disk layout of a Snapshot. Memory holds the cells
+// below the sparse region, trimmed of trailing zeros. Dialect names one of
+// the package's dialects, or is empty for Full.
+type snapshotFile struct {
+	Version      int         `json:"version"`
+	IP           int         `json:"ip"`
+	RelativeBase int         `json:"relative_base"`
+	Halted       bool        `json:"halted"`
+	Input        []int       `json:"input"`
+	Output       []int       `json:"output"`
+	Limit        int         `json:"limit"`
+	Memory       []int       `json:"memory"`
+	Far          map[int]int `json:"far,omitempty"`
+	Dialect      string      `json:"dialect,omitempty"`
+}
+
+// dialects are the dialects a snapshot file can name.
+var dialects = map[string]*Dialect{
+	Full.Name: Full,
+	Day2.Name: Day2,
+	Day5.Name: Day5,
+}
+
+// WriteTo writes the snapshot as versioned JSON. It fails with
+// ErrUnknownDialect for a dialect other than Full, Day2 and Day5.
+func (s *Snapshot) WriteTo(w io.Writer) (int64, error) {
+	if d := s.Dialect; d != nil && dialects[d.Name] != d {
+		return 0, fmt.Errorf("%w: %v", ErrUnknownDialect, d.Name)
+	}
+	f := snapshotFile{
+		Version:      SnapshotVersion,
+		IP:           s.IP,
+		RelativeBase: s.RelativeBase,
+		Halted:       s.Halted,
+		Input:        s.Input,
+		Output:       s.Output,
+		Limit:        s.Memory.Limit,
+		Memory:       s.Memory.Image(),
+		Far:          s.Memory.far,
+	}
+	if s.Dialect != nil && s.Dialect != Full {
+		f.Dialect = s.Dialect.Name
+	}
+	data, err := json.Marshal(&f)
+	if err != nil {
+		return 0, err
+	}
+	n, err := w.Write(append(data, '\n'))
+	return int64(n), err
+}
+
+// Save writes the snapshot to path, replacing the file only once the new
+// state is completely written.
+func (s *Snapshot) Save(path string) error {
+	tmp := path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	if _, err := s.WriteTo(f); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// ReadSnapshot reads a snapshot written by WriteTo.
+func ReadSnapshot(r io.Reader) (*Snapshot, error) {
+	var f snapshotFile
+	if err := json.NewDecoder(r).Decode(&f); err != nil {
+		return nil, err
+	}
+	if f.Version != SnapshotVersion {
+		return nil, fmt.Errorf("%w: %v", ErrSnapshotVersion, f.Version)
+	}
+
+	var d *Dialect
+	if f.Dialect != "" {
+		if d = dialects[f.Dialect]; d == nil {
+			return nil, fmt.Errorf("%w: %v", ErrUnknownDialect, f.Dialect)
+		}
+	}
+
+	mem := NewMemory(f.Memory)
+	if f.Limit > 0 {
+		mem.Limit = f.Limit
+	}
+	for addr, v := range f.Far {
+		if err := mem.Store(addr, v); err != nil {
+			return nil, err
+		}
+	}
+	return &Snapshot{
+		Memory:       mem,
+		IP:           f.IP,
+		RelativeBase: f.RelativeBase,
+		Halted:       f.Halted,
+		Input:        f.Input,
+		Output:       f.Output,
+		Dialect:      d,
+	}, nil
+}
+
+// LoadSnapshot reads a snapshot saved to path.
+func LoadSnapshot(path string) (*Snapshot, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadSnapshot(f)
+}
@@ -0,0 +1,70 @@
+package intcode
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+// relative uses relative mode, which Day5 does not allow.
+var relative = []int{201, 0, 0, 0, 99}
+
+func TestDialectCopies(t *testing.T) {
+	m := NewMachine(relative)
+	m.Dialect = Day5
+
+	var buf bytes.Buffer
+	if _, err := m.Snapshot().WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	saved, err := ReadSnapshot(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	restored := NewMachine(nil)
+	restored.Restore(saved)
+
+	copies := map[string]*Machine{
+		"clone":    m.Clone(),
+		"snapshot": restored,
+	}
+	for name, c := range copies {
+		if c.Dialect != Day5 {
+			t.Errorf("%s: dialect %v, want day5", name, c.Dialect)
+		}
+		if err := c.Run(nil, nil); !errors.Is(err, ErrInvalidMode) {
+			t.Errorf("%s: got %v, want invalid mode", name, err)
+		}
+	}
+}
+
+func TestSnapshotUnknownDialect(t *testing.T) {
+	m := NewMachine(relative)
+	m.Dialect = Full.Subset("custom", []int{POSITION}, HALT)
+	var buf bytes.Buffer
+	if _, err := m.Snapshot().WriteTo(&buf); !errors.Is(err, ErrUnknownDialect) {
+		t.Fatalf("got %v, want %v", err, ErrUnknownDialect)
+	}
+
+	buf.WriteString(`{"version":1,"memory":[99],"dialect":"custom"}`)
+	if _, err := ReadSnapshot(&buf); !errors.Is(err, ErrUnknownDialect) {
+		t.Fatalf("got %v, want %v", err, ErrUnknownDialect)
+	}
+}
+
+func TestRunCompiledDialect(t *testing.T) {
+	called := false
+	code := func(m *Machine) bool {
+		called = true
+		m.Halted = true
+		return false
+	}
+	m := NewMachine(relative)
+	m.Dialect = Day2
+	if err := m.RunCompiled(code, nil, nil); !errors.Is(err, ErrInvalidMode) {
+		t.Fatalf("got %v, want the day2 dialect to reject relative mode", err)
+	}
+	if called {
+		t.Fatal("compiled code ran for a day2 machine")
+	}
+}
@@ -0,0 +1,471 @@
+package intcode
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+var ErrUnknowns = errors.New("bad unknowns")
+
+// Unknown is a memory cell to solve for and the range of values, Min to Max
+// inclusive, it may take.
+type Unknown struct {
+	Addr int
+	Min  int
+	Max  int
+}
+
+// Solver finds the values of unknown cells of a program for which it halts
+// with a wanted value in a target cell, such as the noun and verb of day 2.
+//
+// The program is executed symbolically: the unknowns are variables and every
+// cell holds a polynomial over them. Reads through an address that depends
+// on an unknown give an opaque value, and writes through one end symbolic
+// execution. Whenever an opcode, address, jump or comparison needs the
+// concrete value of an unknown, the search branches over that unknown's
+// range and executes again with it fixed. Unknowns that only feed arithmetic
+// stay symbolic, and the target's polynomial is then solved for them,
+// directly where it is linear in one of them, so only the unknowns that steer
+// the program are searched. In the worst case every unknown is fixed and this
+// is a plain search running the program once per assignment.
+type Solver struct {
+	Program  []int
+	Unknowns []Unknown
+	// MaxSteps bounds every run. An assignment running longer has no
+	// solution.
+	MaxSteps int64
+	// Dialect limits the opcodes and modes the program may use, as it does
+	// for a Machine; nil is Full. Ops keep their meaning from Full.
+	Dialect *Dialect
+
+	// Runs counts the executions done by the last Solve.
+	Runs int
+}
+
+// NewSolver returns a solver for unknowns of program.
+func NewSolver(program []int, unknowns ...Unknown) *Solver {
+	s := Solver{
+		Program:  program,
+		Unknowns: unknowns,
+		MaxSteps: 1 << 20,
+	}
+	return &s
+}
+
+// Formula returns the value of target after the program halts, as a formula
+// in the unknowns named x0, x1, ... It fails if the value is not a
+// polynomial of them, for instance because the program branches on one.
+func (s *Solver) Formula(target int) (string, error) {
+	if err := s.check(); err != nil {
+		return "", err
+	}
+	fixed := make([]bool, len(s.Unknowns))
+	r, err := s.execute(fixed, make([]int, len(s.Unknowns)), target)
+	if err != nil {
+		return "", err
+	}
+	var names []string
+	for j := range s.Unknowns {
+		names = append(names, fmt.Sprintf("x%d", j))
+	}
+	return r.p.format(names), nil
+}
+
+// Solve returns every assignment of the unknowns, in the order they were
+// given, for which the program halts with want at target. Solutions are
+// sorted.
+func (s *Solver) Solve(target int, want int) ([][]int, error) {
+	if err := s.check(); err != nil {
+		return nil, err
+	}
+	s.Runs = 0
+	var solutions [][]int
+
+	fixed := make([]bool, len(s.Unknowns))
+	values := make([]int, len(s.Unknowns))
+	var search func()
+	search = func() {
+		s.Runs++
+		r, err := s.execute(fixed, values, target)
+		var need needError
+		if errors.As(err, &need) {
+			j := need.unknown
+			fixed[j] = true
+			for v := s.Unknowns[j].Min; v <= s.Unknowns[j].Max; v++ {
+				values[j] = v
+				search()
+			}
+			fixed[j] = false
+			return
+		}
+		if err != nil {
+			// A fault or step limit that no free unknown has a say in:
+			// no assignment on this branch halts.
+			return
+		}
+
+		var free []int
+		for j, f := range fixed {
+			if !f {
+				free = append(free, j)
+			}
+		}
+		assign := append([]int(nil), values...)
+		s.enumerate(r.p.add(constant(-want)), free, assign, func(a []int) {
+			for _, addr := range r.reads {
+				if v := evaluate(addr, a); v < 0 || v >= DefaultMemoryLimit {
+					return
+				}
+			}
+			solutions = append(solutions, append([]int(nil), a...))
+		})
+	}
+	search()
+
+	sort.Slice(solutions, func(i, j int) bool {
+		return less(solutions[i], solutions[j])
+	})
+	return solutions, nil
+}
+
+func (s *Solver) check() error {
+	if len(s.Unknowns) > maxUnknowns {
+		return fmt.Errorf("%w: more than %d", ErrUnknowns, maxUnknowns)
+	}
+	seen := make(map[int]bool)
+	for _, u := range s.Unknowns {
+		if u.Min > u.Max {
+			return fmt.Errorf("%w: empty range %d..%d at %d", ErrUnknowns, u.Min, u.Max, u.Addr)
+		}
+		if u.Addr < 0 || seen[u.Addr] {
+			return fmt.Errorf("%w: address %d", ErrUnknowns, u.Addr)
+		}
+		seen[u.Addr] = true
+	}
+	return nil
+}
+
+// enumerate calls emit with every assignment of the free unknowns in a for
+// which p is 0.
+func (s *Solver) enumerate(p poly, free []int, a []int, emit func([]int)) {
+	if c, ok := p.value(); ok {
+		if c == 0 {
+			s.every(free, a, emit)
+		}
+		return
+	}
+
+	// Unknowns p does not depend on take every value.
+	used := make(map[int]bool)
+	for _, j := range p.deps() {
+		used[j] = true
+	}
+	var rest []int
+	for _, j := range free {
+		if used[j] {
+			rest = append(rest, j)
+		}
+	}
+	if len(rest) < len(free) {
+		var unused []int
+		for _, j := range free {
+			if !used[j] {
+				unused = append(unused, j)
+			}
+		}
+		s.enumerate(p, rest, a, func(a []int) {
+			s.every(unused, a, emit)
+		})
+		return
+	}
+
+	// Solve directly for the last unknown if p is linear in it, and keep
+	// such an unknown for last otherwise.
+	if len(free) == 1 && p.degree(free[0]) == 1 {
+		if x, ok, exact := s.root(p, free[0]); exact {
+			if ok {
+				a[free[0]] = x
+				emit(a)
+			}
+			return
+		}
+	}
+	j := free[0]
+	if len(free) > 1 && p.degree(j) == 1 {
+		j = free[1]
+	}
+	var others []int
+	for _, f := range free {
+		if f != j {
+			others = append(others, f)
+		}
+	}
+	for v := s.Unknowns[j].Min; v <= s.Unknowns[j].Max; v++ {
+		a[j] = v
+		s.enumerate(p.substitute(j, v), others, a, emit)
+	}
+}
+
+// root solves p = c*x + d = 0 for x, the unknown j. ok reports a root in
+// the range of j. exact reports whether integer division finds every root:
+// it does when c*x + d cannot overflow in that range, as it is then
+// monotonic and never wraps around.
+func (s *Solver) root(p poly, j int) (x int, ok bool, exact bool) {
+	var one monomial
+	one[j] = 1
+	c, d := p[one], p[monomial{}]
+	u := s.Unknowns[j]
+	for _, x := range []int{u.Min, u.Max} {
+		cx, err := mulChecked(c, x)
+		if err != nil {
+			return 0, false, false
+		}
+		if _, err := addChecked(cx, d); err != nil {
+			return 0, false, false
+		}
+	}
+	if d == minInt || d%c != 0 {
+		return 0, false, true
+	}
+	x = -d / c
+	return x, x >= u.Min && x <= u.Max, true
+}
+
+// every calls emit with every assignment of the unknowns in js.
+func (s *Solver) every(js []int, a []int, emit func([]int)) {
+	if len(js) == 0 {
+		emit(a)
+		return
+	}
+	j := js[0]
+	for v := s.Unknowns[j].Min; v <= s.Unknowns[j].Max; v++ {
+		a[j] = v
+		s.every(js[1:], a, emit)
+	}
+}
+
+func evaluate(p poly, a []int) int {
+	sum := 0
+	for m, c := range p {
+		for j, e := range m {
+			for ; e > 0; e-- {
+				c *= a[j]
+			}
+		}
+		sum += c
+	}
+	return sum
+}
+
+func less(a []int, b []int) bool {
+	for i := range a {
+		if a[i] != b[i] {
+			return a[i] < b[i]
+		}
+	}
+	return false
+}
+
+// needError asks for the concrete value of an unknown.
+type needError struct {
+	unknown int
+}
+
+func (e needError) Error() string {
+	return fmt.Sprintf("unknown %d needed concretely", e.unknown)
+}
+
+// sym is the symbolic value of a cell. opaque lists the unknowns an opaque
+// value depends on; it is nil for a known polynomial.
+type sym struct {
+	p      poly
+	opaque []int
+}
+
+// symResult is the outcome of a symbolic run: the target's polynomial and the
+// addresses of opaque reads, which are only valid for assignments keeping
+// them in memory.
+type symResult struct {
+	p     poly
+	reads []poly
+}
+
+type symbolic struct {
+	mem   map[int]sym
+	reads []poly
+}
+
+// concrete returns the value of v, or a needError for an unknown it depends
+// on.
+func concrete(v sym) (int, error) {
+	if v.opaque != nil {
+		return 0, needError{v.opaque[0]}
+	}
+	if c, ok := v.p.value(); ok {
+		return c, nil
+	}
+	return 0, needError{v.p.deps()[0]}
+}
+
+func (st *symbolic) load(addr int) sym {
+	if v, ok := st.mem[addr]; ok {
+		return v
+	}
+	return sym{p: constant(0)}
+}
+
+// execute runs the program with the fixed unknowns set to values and the
+// others symbolic, and returns the value left at target.
+func (s *Solver) execute(fixed []bool, values []int, target int) (symResult, error) {
+	st := symbolic{
+		mem: make(map[int]sym),
+	}
+	for addr, v := range s.Program {
+		if v != 0 {
+			st.mem[addr] = sym{p: constant(v)}
+		}
+	}
+	for j, u := range s.Unknowns {
+		if fixed[j] {
+			st.mem[u.Addr] = sym{p: constant(values[j])}
+		} else {
+			st.mem[u.Addr] = sym{p: variable(j)}
+		}
+	}
+
+	d := s.Dialect
+	if d == nil {
+		d = Full
+	}
+	ip, rb := 0, 0
+	for steps := int64(0); ; steps++ {
+		if s.MaxSteps > 0 && steps >= s.MaxSteps {
+			return symResult{}, ErrStepLimit
+		}
+		if ip < 0 || ip >= DefaultMemoryLimit {
+			return symResult{}, ErrIPOutOfBounds
+		}
+		code, err := concrete(st.load(ip))
+		if err != nil {
+			return symResult{}, err
+		}
+		inst := decodeCode(code, d)
+		if inst.op == nil {
+			return symResult{}, &DialectError{Dialect: d.Name, Opcode: inst.opcode, Mode: -1}
+		}
+		if inst.opcode == HALT {
+			break
+		}
+		if ip+inst.arity >= DefaultMemoryLimit {
+			return symResult{}, ErrIPOutOfBounds
+		}
+
+		// param holds the values read, addr the address of a write.
+		var param [3]sym
+		addr := 0
+		for k := 0; k < inst.arity; k++ {
+			if k == inst.badParam {
+				return symResult{}, &DialectError{Dialect: d.Name, Opcode: inst.opcode, Mode: inst.modes[k]}
+			}
+			raw := st.load(ip + 1 + k)
+			if inst.modes[k] == IMMEDIATE {
+				if k == inst.target {
+					return symResult{}, ErrImmediateWrite
+				}
+				param[k] = raw
+				continue
+			}
+			a := raw.p
+			if inst.modes[k] == RELATIVE {
+				a = a.add(constant(rb))
+			}
+			if c, ok := a.value(); ok && raw.opaque == nil {
+				if c < 0 {
+					return symResult{}, ErrNegativeAddress
+				}
+				if c >= DefaultMemoryLimit {
+					return symResult{}, ErrMemoryLimit
+				}
+				addr = c
+				param[k] = st.load(c)
+				continue
+			}
+			if k == inst.target || raw.opaque != nil {
+				_, err := concrete(sym{p: a, opaque: raw.opaque})
+				return symResult{}, err
+			}
+			st.reads = append(st.reads, a)
+			param[k] = sym{p: constant(0), opaque: a.deps()}
+		}
+
+		next := ip + 1 + inst.arity
+		switch inst.opcode {
+		case ADD:
+			st.mem[addr] = combine(param[0], param[1], param[0].p.add(param[1].p))
+		case MULTIPLY:
+			p, ok := param[0].p.mul(param[1].p)
+			if !ok && param[0].opaque == nil && param[1].opaque == nil {
+				// Too large to track: make a factor concrete.
+				if _, err := concrete(param[0]); err != nil {
+					return symResult{}, err
+				}
+				_, err := concrete(param[1])
+				return symResult{}, err
+			}
+			st.mem[addr] = combine(param[0], param[1], p)
+		case LESS_THAN, EQUALS:
+			a, err := concrete(param[0])
+			if err != nil {
+				return symResult{}, err
+			}
+			b, err := concrete(param[1])
+			if err != nil {
+				return symResult{}, err
+			}
+			if (inst.opcode == LESS_THAN && a < b) || (inst.opcode == EQUALS && a == b) {
+				st.mem[addr] = sym{p: constant(1)}
+			} else {
+				st.mem[addr] = sym{p: constant(0)}
+			}
+		case JUMP_IF_TRUE, JUMP_IF_FALSE:
+			a, err := concrete(param[0])
+			if err != nil {
+				return symResult{}, err
+			}
+			if (a != 0) == (inst.opcode == JUMP_IF_TRUE) {
+				if next, err = concrete(param[1]); err != nil {
+					return symResult{}, err
+				}
+			}
+		case RELATIVE_BASE:
+			a, err := concrete(param[0])
+			if err != nil {
+				return symResult{}, err
+			}
+			rb += a
+		case STORE:
+			// There is no input to give.
+			return symResult{}, ErrNoInput
+		case LOAD:
+			// Outputs are not part of the goal.
+		}
+		ip = next
+	}
+
+	v := st.load(target)
+	if v.opaque != nil {
+		_, err := concrete(v)
+		return symResult{}, err
+	}
+	return symResult{p: v.p, reads: st.reads}, nil
+}
+
+// combine returns the value of an operation on a and b with polynomial p,
+// opaque if either operand is.
+func combine(a sym, b sym, p poly) sym {
+	if a.opaque == nil && b.opaque == nil {
+		return sym{p: p}
+	}
+	return sym{p: constant(0), opaque: append(append([]int(nil), a.opaque...), b.opaque...)}
+}
@@ -0,0 +1,108 @@
+package intcode
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+// randomDay2 returns a day 2 style program of n cells: mostly ADD and MUL
+// on addresses inside the program, with noun and verb at 1 and 2. Codes get
+// junk mode digits, including digits past the arity and above the fifth.
+func randomDay2(r *rand.Rand, n int, full bool) []int {
+	program := make([]int, n)
+	for i := range program {
+		program[i] = r.Intn(n)
+	}
+	opcodes := []int{ADD, ADD, MULTIPLY, MULTIPLY, HALT}
+	if full {
+		opcodes = append(opcodes, JUMP_IF_TRUE, JUMP_IF_FALSE, LESS_THAN, EQUALS, RELATIVE_BASE)
+	}
+	modes := []int{0, 0, 0, 0, 1, 2, 5}
+	for i := 0; i < n; i += 4 {
+		code := opcodes[r.Intn(len(opcodes))]
+		if r.Intn(3) == 0 {
+			for k, scale := 0, 100; k < 3; k, scale = k+1, scale*10 {
+				code += scale * modes[r.Intn(len(modes))]
+			}
+		}
+		if r.Intn(8) == 0 {
+			code += 100000 * (1 + r.Intn(9))
+		}
+		program[i] = code
+	}
+	return program
+}
+
+// bruteForce runs every noun and verb below size and returns them by the
+// value left at 0, for the runs that halt.
+func bruteForce(program []int, size int, d *Dialect) map[int][][]int {
+	results := make(map[int][][]int)
+	for noun := 0; noun < size; noun++ {
+		for verb := 0; verb < size; verb++ {
+			m := NewMachine(program)
+			m.Dialect = d
+			m.MaxSteps = 1000
+			m.Write(1, noun)
+			m.Write(2, verb)
+			if err := m.Run(nil, nil); err != nil {
+				continue
+			}
+			results[m.Read(0)] = append(results[m.Read(0)], []int{noun, verb})
+		}
+	}
+	return results
+}
+
+func TestSolverMatchesMachine(t *testing.T) {
+	const size = 16
+	r := rand.New(rand.NewSource(2))
+	for round := 0; round < 200; round++ {
+		d := Day2
+		if round%2 == 1 {
+			d = Full
+		}
+		program := randomDay2(r, 12+r.Intn(16), d == Full)
+		want := bruteForce(program, size, d)
+
+		s := NewSolver(program, Unknown{Addr: 1, Max: size - 1}, Unknown{Addr: 2, Max: size - 1})
+		s.MaxSteps = 1000
+		s.Dialect = d
+		targets := []int{-1, 0, 19690720}
+		for v := range want {
+			targets = append(targets, v)
+		}
+		for _, target := range targets {
+			got, err := s.Solve(0, target)
+			if err != nil {
+				t.Fatalf("%v: %v", program, err)
+			}
+			if len(got) == 0 && len(want[target]) == 0 {
+				continue
+			}
+			if !reflect.DeepEqual(got, want[target]) {
+				t.Fatalf("%s program %v, result %d: solver gives %v, machine %v",
+					d.Name, program, target, got, want[target])
+			}
+		}
+	}
+}
+
+func TestSolverJunkModes(t *testing.T) {
+	// 4599 halts: its mode digits are past the arity of HALT.
+	program := []int{1, 0, 0, 0, 4599, 5, 6, 7}
+	s := NewSolver(program, Unknown{Addr: 1, Max: 99}, Unknown{Addr: 2, Max: 99})
+	want := bruteForce(program, 100, Full)
+	if len(want) == 0 {
+		t.Fatal("no run halted")
+	}
+	for target, solutions := range want {
+		got, err := s.Solve(0, target)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, solutions) {
+			t.Fatalf("result %d: solver gives %v, machine %v", target, got, solutions)
+		}
+	}
+}
@@ -0,0 +1,341 @@
+package intcode
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// A trace log starts with traceMagic, the format version and the snapshot of
+// the machine when tracing began, as a length-prefixed JSON snapshot. Every
+// executed instruction follows as signed varints: IP, raw instruction, the
+// resolved parameters (values, or addresses for write targets) and, for
+// instructions that write memory, the value written. Input and output values
+// are implied: an input is the value written by IN, an output the first
+// parameter of OUT.
+var traceMagic = []byte("ICTR")
+
+const traceVersion = 1
+
+var (
+	ErrNotTrace      = errors.New("not an intcode trace")
+	ErrReplayDiverge = errors.New("replay diverged from trace")
+)
+
+// TraceStep is one executed instruction read back from a trace.
+type TraceStep struct {
+	IP         int
+	Code       int
+	Params     [3]int
+	Arity      int
+	Write      bool
+	WriteAddr  int
+	WriteValue int
+}
+
+// Opcode returns the opcode of the executed instruction.
+func (s TraceStep) Opcode() int {
+	return s.Code % 100
+}
+
+// Input returns the value read by an IN instruction.
+func (s TraceStep) Input() (int, bool) {
+	return s.WriteValue, s.Opcode() == STORE
+}
+
+// Output returns the value written by an OUT instruction.
+func (s TraceStep) Output() (int, bool) {
+	return s.Params[0], s.Opcode() == LOAD
+}
+
+func (s TraceStep) String() string {
+	var params []string
+	for k := 0; k < s.Arity; k++ {
+		params = append(params, fmt.Sprint(s.Params[k]))
+	}
+	text := fmt.Sprintf("%6d: %-4s(%d) %v", s.IP, Mnemonic(s.Opcode()), s.Code, params)
+	if v, ok := s.Input(); ok {
+		text += fmt.Sprintf(" input %d", v)
+	} else if v, ok := s.Output(); ok {
+		text += fmt.Sprintf(" output %d", v)
+	}
+	if s.Write {
+		text += fmt.Sprintf(" [%d] <- %d", s.WriteAddr, s.WriteValue)
+	}
+	return text
+}
+
+// TraceWriter records every instruction executed by the machine it is
+// attached to through Machine.Trace.
+type TraceWriter struct {
+	Steps int64
+
+	w   *bufio.Writer
+	buf [binary.MaxVarintLen64]byte
+	err error
+}
+
+// NewTraceWriter starts a trace of m on w. Assign it to m.Trace to record.
+func NewTraceWriter(w io.Writer, m *Machine) (*TraceWriter, error) {
+	var start bytes.Buffer
+	if _, err := m.Snapshot().WriteTo(&start); err != nil {
+		return nil, err
+	}
+
+	t := TraceWriter{
+		w: bufio.NewWriter(w),
+	}
+	t.w.Write(traceMagic)
+	t.w.WriteByte(traceVersion)
+	t.varint(start.Len())
+	t.w.Write(start.Bytes())
+	return &t, t.err
+}
+
+// Flush writes buffered records to the underlying writer.
+func (t *TraceWriter) Flush() error {
+	if t.err != nil {
+		return t.err
+	}
+	return t.w.Flush()
+}
+
+func (t *TraceWriter) varint(v int) {
+	n := binary.PutVarint(t.buf[:], int64(v))
+	if _, err := t.w.Write(t.buf[:n]); err != nil && t.err == nil {
+		t.err = err
+	}
+}
+
+// record logs an instruction that has just executed.
+func (t *TraceWriter) record(m *Machine, ip int, code int, param *[3]int, n int) {
+	t.varint(ip)
+	t.varint(code)
+	for k := 0; k < n; k++ {
+		t.varint(param[k])
+	}
+	if writes(code % 100) {
+		t.varint(m.Memory.Read(param[targetOf(code%100)]))
+	}
+	t.Steps++
+}
+
+// targetOf returns the index of the write target parameter of opcode.
+func targetOf(opcode int) int {
+	return Full.Op(opcode).Arity - 1
+}
+
+func writes(opcode int) bool {
+	op := Full.Op(opcode)
+	return op != nil && op.Writes
+}
+
+// TraceReader reads back a trace log.
+type TraceReader struct {
+	// Start is the machine state when the trace began.
+	Start *Snapshot
+
+	r *bufio.Reader
+}
+
+// NewTraceReader reads the header of a trace log.
+func NewTraceReader(r io.Reader) (*TraceReader, error) {
+	t := TraceReader{
+		r: bufio.NewReader(r),
+	}
+
+	header := make([]byte, len(traceMagic)+1)
+	if _, err := io.ReadFull(t.r, header); err != nil || !bytes.Equal(header[:len(traceMagic)], traceMagic) {
+		return nil, ErrNotTrace
+	}
+	if header[len(traceMagic)] != traceVersion {
+		return nil, fmt.Errorf("%w: trace version %v", ErrNotTrace, header[len(traceMagic)])
+	}
+
+	size, err := binary.ReadVarint(t.r)
+	if err != nil {
+		return nil, err
+	}
+	start := make([]byte, size)
+	if _, err := io.ReadFull(t.r, start); err != nil {
+		return nil, err
+	}
+	t.Start, err = ReadSnapshot(bytes.NewReader(start))
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// Next returns the next recorded step, or io.EOF at the end of the log.
+func (t *TraceReader) Next() (TraceStep, error) {
+	var s TraceStep
+
+	ip, err := binary.ReadVarint(t.r)
+	if err != nil {
+		return s, err
+	}
+	s.IP = int(ip)
+
+	code, err := binary.ReadVarint(t.r)
+	if err != nil {
+		return s, unexpected(err)
+	}
+	s.Code = int(code)
+	n, ok := arity(s.Opcode())
+	if !ok {
+		return s, fmt.Errorf("%w: bad opcode %v at ip %v", ErrNotTrace, s.Code, s.IP)
+	}
+	s.Arity = n
+
+	var fields []*int
+	for k := 0; k < n; k++ {
+		fields = append(fields, &s.Params[k])
+	}
+	if writes(s.Opcode()) {
+		s.Write = true
+		fields = append(fields, &s.WriteValue)
+	}
+	for _, f := range fields {
+		v, err := binary.ReadVarint(t.r)
+		if err != nil {
+			return s, unexpected(err)
+		}
+		*f = int(v)
+	}
+	if s.Write {
+		s.WriteAddr = s.Params[targetOf(s.Opcode())]
+	}
+	return s, nil
+}
+
+func unexpected(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+// ReadTrace reads a whole trace log.
+func ReadTrace(r io.Reader) (*Snapshot, []TraceStep, error) {
+	t, err := NewTraceReader(r)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var steps []TraceStep
+	for {
+		s, err := t.Next()
+		if err == io.EOF {
+			return t.Start, steps, nil
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		steps = append(steps, s)
+	}
+}
+
+const replayCheckpoint = 4096
+
+// Replay re-executes a recorded trace deterministically, feeding the machine
+// the recorded inputs and checking every step against the log.
+type Replay struct {
+	Machine *Machine
+	Steps   []TraceStep
+	// Pos is the number of steps executed so far.
+	Pos int
+
+	start       *Snapshot
+	checkpoints map[int]*Snapshot
+}
+
+// NewReplay reads a trace log and positions the replay at its first step.
+func NewReplay(r io.Reader) (*Replay, error) {
+	start, steps, err := ReadTrace(r)
+	if err != nil {
+		return nil, err
+	}
+
+	rp := Replay{
+		Machine:     NewMachine(nil),
+		Steps:       steps,
+		start:       start,
+		checkpoints: make(map[int]*Snapshot),
+	}
+	rp.restore(start)
+	return &rp, nil
+}
+
+// restore loads a state without its queued I/O, which the replay supplies
+// from the log instead.
+func (rp *Replay) restore(s *Snapshot) {
+	rp.Machine.Restore(s)
+	rp.Machine.pendingIn = nil
+	rp.Machine.pendingOut = nil
+}
+
+// Step executes the next recorded step and verifies the outcome.
+func (rp *Replay) Step() error {
+	if rp.Pos >= len(rp.Steps) {
+		return io.EOF
+	}
+	m := rp.Machine
+	s := rp.Steps[rp.Pos]
+
+	if m.IP != s.IP || m.Read(m.IP) != s.Code {
+		return rp.diverged("executing %d (%d), trace has %d (%d)", m.IP, m.Read(m.IP), s.IP, s.Code)
+	}
+	if v, ok := s.Input(); ok {
+		m.Feed(v)
+	}
+	if err := m.Step(); err != nil {
+		return err
+	}
+	if s.Write && m.Read(s.WriteAddr) != s.WriteValue {
+		return rp.diverged("[%d] = %d, trace has %d", s.WriteAddr, m.Read(s.WriteAddr), s.WriteValue)
+	}
+	if v, ok := s.Output(); ok {
+		out := m.TakeOutput()
+		if len(out) != 1 || out[0] != v {
+			return rp.diverged("output %v, trace has %d", out, v)
+		}
+	}
+
+	rp.Pos++
+	if rp.Pos%replayCheckpoint == 0 && rp.checkpoints[rp.Pos] == nil {
+		rp.checkpoints[rp.Pos] = m.Snapshot()
+	}
+	return nil
+}
+
+// Seek positions the replay after step pos, going back to the closest
+// checkpoint when seeking backwards.
+func (rp *Replay) Seek(pos int) error {
+	if pos < 0 || pos > len(rp.Steps) {
+		return fmt.Errorf("step %d out of range 0..%d", pos, len(rp.Steps))
+	}
+	if pos < rp.Pos {
+		from, state := 0, rp.start
+		for at, s := range rp.checkpoints {
+			if at <= pos && at > from {
+				from, state = at, s
+			}
+		}
+		rp.Pos = from
+		rp.restore(state)
+	}
+	for rp.Pos < pos {
+		if err := rp.Step(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (rp *Replay) diverged(format string, args ...interface{}) error {
+	return fmt.Errorf("%w at step %d: %s", ErrReplayDiverge, rp.Pos, fmt.Sprintf(format, args...))
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/jingqiuELE/advent_code_2019/intcode"
+	flag "github.com/spf13/pflag"
+)
+
+func main() {
+	var traceFile string
+	var seek int
+	var count int
+
+	flag.StringVarP(&traceFile, "trace file name", "t", "", "")
+	flag.IntVarP(&seek, "seek", "s", -1, "step to stop at, defaults to the end of the trace")
+	flag.IntVarP(&count, "count", "n", 0, "number of steps to list from the seek position")
+	flag.Parse()
+
+	f, err := os.Open(traceFile)
+	if err != nil {
+		log.Fatal("Failed to open trace file!", err)
+	}
+	rp, err := intcode.NewReplay(f)
+	f.Close()
+	if err != nil {
+		log.Fatal("Failed to read trace file!", err)
+	}
+
+	if seek < 0 || seek > len(rp.Steps) {
+		seek = len(rp.Steps)
+	}
+	if err := rp.Seek(seek); err != nil {
+		log.Fatal(err)
+	}
+
+	m := rp.Machine
+	fmt.Printf("step %d/%d: ip=%d, rb=%d, halted=%v\n", rp.Pos, len(rp.Steps), m.IP, m.RelativeBase, m.Halted)
+	if inst, err := m.Memory.Decode(m.IP); err == nil && !m.Halted {
+		fmt.Printf("next: %s\n", intcode.Line{Addr: m.IP, Inst: &inst})
+	}
+
+	for k := 0; k < count && rp.Pos < len(rp.Steps); k++ {
+		fmt.Printf("#%-8d %s\n", rp.Pos, rp.Steps[rp.Pos])
+		if err := rp.Step(); err != nil {
+			log.Fatal(err)
+		}
+	}
+}